@@ -0,0 +1,59 @@
+//go:build unix && !darwin
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runClipboard runs the command with --clipboard on a one-file tree, with
+// only bin on PATH, and returns its standard output and error.
+func runClipboard(t *testing.T, bin string) (stdout, stderr string) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "--clipboard", root)
+	cmd.Env = append(os.Environ(), "MYREPOREADER_RUN_MAIN=1", "PATH="+bin, "WAYLAND_DISPLAY=")
+	var out, errOut strings.Builder
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("myreporeader --clipboard: %v\n%s", err, errOut.String())
+	}
+	return out.String(), errOut.String()
+}
+
+func TestClipboard(t *testing.T) {
+	bin := t.TempDir()
+	clip := filepath.Join(t.TempDir(), "clipboard")
+	script := "#!/bin/sh\nexec /bin/cat > " + clip + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "xclip"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := runClipboard(t, bin)
+	if stdout != "" {
+		t.Errorf("--clipboard also wrote to stdout:\n%s", stdout)
+	}
+	data, err := os.ReadFile(clip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "### File: main.go\n") {
+		t.Errorf("clipboard lacks the document:\n%s", data)
+	}
+}
+
+func TestClipboardFallback(t *testing.T) {
+	stdout, stderr := runClipboard(t, t.TempDir())
+	if !strings.Contains(stderr, "Warning: no clipboard tool found") {
+		t.Errorf("stderr lacks the warning:\n%s", stderr)
+	}
+	if !strings.Contains(stdout, "### File: main.go\n") {
+		t.Errorf("document not written to stdout:\n%s", stdout)
+	}
+}
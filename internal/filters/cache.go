@@ -0,0 +1,57 @@
+package filters
+
+import (
+	"os"
+	"sync"
+)
+
+// Cache memoizes content sniffs and compiled patterns for one run, so each
+// file is opened at most once even though several passes classify it. The
+// zero value is ready to use; a run drops its Cache when it finishes, so
+// nothing outlives the run.
+type Cache struct {
+	sniffs   sync.Map // sniffKey -> bool
+	patterns sync.Map // pattern string -> Pattern
+}
+
+// Sniff results are keyed by path, size, and mod time, so a file changed
+// in place is sniffed again.
+type sniffKey struct {
+	path    string
+	size    int64
+	modTime int64
+}
+
+// IsTextFile is IsTextFile with the content sniff cached.
+func (c *Cache) IsTextFile(path string) bool {
+	return hasTextyName(path) || c.sniff(path) || hintExt(path) != ""
+}
+
+func (c *Cache) sniff(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return isProbablyTextFile(path)
+	}
+	key := sniffKey{path: path, size: info.Size(), modTime: info.ModTime().UnixNano()}
+	if text, ok := c.sniffs.Load(key); ok {
+		return text.(bool)
+	}
+	text := isProbablyTextFile(path)
+	c.sniffs.Store(key, text)
+	return text
+}
+
+// MatchPattern is MatchPattern with each distinct pattern compiled once.
+func (c *Cache) MatchPattern(rel, pattern string) bool {
+	if p, ok := c.patterns.Load(pattern); ok {
+		return p.(Pattern).Match(rel)
+	}
+	p := Compile(pattern)
+	c.patterns.Store(pattern, p)
+	return p.Match(rel)
+}
+
+// IsDenied is IsDenied with the patterns compiled once.
+func (c *Cache) IsDenied(absPath string, extra ...string) bool {
+	return isDenied(absPath, extra, c.MatchPattern)
+}
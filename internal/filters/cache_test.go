@@ -0,0 +1,60 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheIsTextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte("plain text\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Cache
+	if !c.IsTextFile(path) {
+		t.Fatal("text file sniffed as binary")
+	}
+	// Same size and mod time: the cached sniff stands.
+	if err := os.WriteFile(path, []byte("plain\x00text\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsTextFile(path) {
+		t.Error("cached sniff not reused")
+	}
+
+	// Nothing is shared between caches or with the uncached helper.
+	var fresh Cache
+	if fresh.IsTextFile(path) {
+		t.Error("new Cache reused another's sniff")
+	}
+	if IsTextFile(path) {
+		t.Error("IsTextFile reused a Cache's sniff")
+	}
+}
+
+func TestCacheMatchPattern(t *testing.T) {
+	var c Cache
+	for range 2 {
+		if !c.MatchPattern("a/b/c.log", "*.log") {
+			t.Error(`"*.log" does not match a/b/c.log`)
+		}
+		if c.MatchPattern("src/x/a.go", "src/*.go") {
+			t.Error(`"src/*.go" matches src/x/a.go`)
+		}
+	}
+	if !c.IsDenied("/home/u/.ssh/id_ed25519") || c.IsDenied("/home/u/main.go") {
+		t.Error("Cache.IsDenied disagrees with the deny-list")
+	}
+	if !c.IsDenied("/r/.env", ".env") {
+		t.Error("Cache.IsDenied ignores extra patterns")
+	}
+}
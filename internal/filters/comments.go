@@ -0,0 +1,84 @@
+package filters
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CommentSyntax describes how a language writes comments: prefixes that
+// start a line comment and the delimiters of a block comment, if any.
+type CommentSyntax struct {
+	Line       []string
+	BlockStart string
+	BlockEnd   string
+}
+
+var (
+	cStyle    = CommentSyntax{Line: []string{"//"}, BlockStart: "/*", BlockEnd: "*/"}
+	hashStyle = CommentSyntax{Line: []string{"#"}}
+	dashStyle = CommentSyntax{Line: []string{"--"}}
+	xmlStyle  = CommentSyntax{BlockStart: "<!--", BlockEnd: "-->"}
+)
+
+// CommentSyntaxes maps lowercase extensions to their comment syntax.
+// Detection is a per-line prefix check, so it is a best-effort count.
+var CommentSyntaxes = map[string]CommentSyntax{
+	".go": cStyle, ".c": cStyle, ".h": cStyle, ".cpp": cStyle, ".cc": cStyle, ".cxx": cStyle,
+	".hpp": cStyle, ".hh": cStyle, ".m": cStyle, ".mm": cStyle, ".java": cStyle, ".kt": cStyle,
+	".kts": cStyle, ".scala": cStyle, ".swift": cStyle, ".cs": cStyle, ".rs": cStyle, ".dart": cStyle,
+	".js": cStyle, ".mjs": cStyle, ".cjs": cStyle, ".jsx": cStyle, ".ts": cStyle, ".tsx": cStyle,
+	".proto": cStyle, ".scss": cStyle, ".less": cStyle, ".groovy": cStyle, ".gradle": cStyle,
+	".py": hashStyle, ".pyi": hashStyle, ".rb": hashStyle, ".pl": hashStyle, ".r": hashStyle,
+	".jl": hashStyle, ".ex": hashStyle, ".exs": hashStyle, ".sh": hashStyle, ".bash": hashStyle,
+	".zsh": hashStyle, ".fish": hashStyle, ".ps1": hashStyle, ".yaml": hashStyle, ".yml": hashStyle,
+	".toml": hashStyle, ".tfvars": hashStyle, ".mk": hashStyle, ".cmake": hashStyle,
+	".ini": {Line: []string{";", "#"}},
+	".sql": dashStyle, ".hs": dashStyle, ".elm": dashStyle,
+	".lua": {Line: []string{"--"}, BlockStart: "--[[", BlockEnd: "]]"},
+	".erl": {Line: []string{"%"}}, ".tex": {Line: []string{"%"}},
+	".clj": {Line: []string{";"}}, ".lisp": {Line: []string{";"}}, ".el": {Line: []string{";"}},
+	".html": xmlStyle, ".htm": xmlStyle, ".xml": xmlStyle, ".svg": xmlStyle, ".vue": xmlStyle,
+	".svelte": xmlStyle, ".md": xmlStyle,
+
+	".css": {BlockStart: "/*", BlockEnd: "*/"},
+	".php": {Line: []string{"//", "#"}, BlockStart: "/*", BlockEnd: "*/"},
+	".tf":  {Line: []string{"#", "//"}, BlockStart: "/*", BlockEnd: "*/"},
+	".ml":  {BlockStart: "(*", BlockEnd: "*)"},
+}
+
+// Extensionless files with a known comment syntax.
+var commentSyntaxNames = map[string]CommentSyntax{
+	"Makefile": hashStyle, "Dockerfile": hashStyle, "Gemfile": hashStyle, "Rakefile": hashStyle,
+	"Procfile": hashStyle, ".gitignore": hashStyle, ".gitattributes": hashStyle,
+}
+
+// CommentSyntaxFor returns the comment syntax for path, looked up by
+// extension and then by file name. ok is false for unknown languages.
+func CommentSyntaxFor(path string) (syntax CommentSyntax, ok bool) {
+	base := filepath.Base(path)
+	if s, ok := CommentSyntaxes[strings.ToLower(filepath.Ext(base))]; ok {
+		return s, true
+	}
+	s, ok := commentSyntaxNames[base]
+	return s, ok
+}
+
+// IsComment reports whether line is a comment line. inBlock says whether a
+// block comment is open at the start of the line; the returned open says
+// whether one is still open after it. Only lines that start with a comment
+// marker, or lie inside a block comment, count as comments.
+func (s CommentSyntax) IsComment(line string, inBlock bool) (comment bool, open bool) {
+	line = strings.TrimSpace(line)
+	if inBlock {
+		return true, !strings.Contains(line, s.BlockEnd)
+	}
+	if s.BlockStart != "" && strings.HasPrefix(line, s.BlockStart) {
+		return true, !strings.Contains(line[len(s.BlockStart):], s.BlockEnd)
+	}
+	for _, prefix := range s.Line {
+		if strings.HasPrefix(line, prefix) {
+			return true, false
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,45 @@
+package filters
+
+import (
+	"strings"
+	"testing"
+)
+
+// commentLines counts the comment lines of content as the Summary does.
+func commentLines(t *testing.T, path string, content string) int {
+	t.Helper()
+	syntax, ok := CommentSyntaxFor(path)
+	if !ok {
+		t.Fatalf("no comment syntax for %s", path)
+	}
+	n, inBlock := 0, false
+	for _, line := range strings.Split(content, "\n") {
+		var comment bool
+		if comment, inBlock = syntax.IsComment(line, inBlock); comment {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCommentLines(t *testing.T) {
+	for _, tc := range []struct {
+		path, content string
+		want          int
+	}{
+		{"main.go", "// Package main.\npackage main\n\n/*\n  block\n*/\nfunc f() {} // trailing\n/* one line */\n", 5},
+		{"app.py", "#!/usr/bin/env python3\n# comment\nx = 1  # trailing\n  # indented\n", 3},
+		{"q.sql", "-- header\nSELECT 1; -- trailing\n", 1},
+		{"page.html", "<!-- a\nb -->\n<p>x</p>\n<!-- c -->\n", 3},
+		{"init.lua", "--[[ block\nstill ]]\n-- line\nprint(1)\n", 3},
+		{"setup.ini", "; semicolon\n# hash\nkey = value\n", 2},
+		{"Makefile", "# build\nall:\n\tgo build\n", 1},
+	} {
+		if got := commentLines(t, tc.path, tc.content); got != tc.want {
+			t.Errorf("%s: %d comment lines, want %d", tc.path, got, tc.want)
+		}
+	}
+	if _, ok := CommentSyntaxFor("data.unknown"); ok {
+		t.Error("comment syntax found for an unknown extension")
+	}
+}
@@ -0,0 +1,35 @@
+package filters
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sensitive paths whose contents are never printed, regardless of any
+// other include or ignore rule. Patterns use MatchPattern syntax and are
+// matched against the absolute path, so ".ssh/" covers any ~/.ssh tree;
+// patterns with a slash need a leading "**/" to match at any depth.
+var DenyPatterns = []string{
+	".ssh/", ".gnupg/",
+	"**/.aws/credentials", ".azure/", "**/.config/gcloud/", "**/.kube/config", "**/.docker/config.json",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	".netrc", ".pgpass", ".git-credentials",
+}
+
+// IsDenied reports whether the absolute path matches the deny-list or one
+// of the extra patterns.
+func IsDenied(absPath string, extra ...string) bool {
+	return isDenied(absPath, extra, MatchPattern)
+}
+
+func isDenied(absPath string, extra []string, match func(rel, pattern string) bool) bool {
+	rel := strings.TrimPrefix(filepath.ToSlash(absPath), "/")
+	for _, pats := range [][]string{DenyPatterns, extra} {
+		for _, pat := range pats {
+			if match(rel, pat) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package filters
+
+import "testing"
+
+func TestIsDenied(t *testing.T) {
+	for _, tc := range []struct {
+		path  string
+		extra []string
+		want  bool
+	}{
+		{"/home/u/.ssh/id_ed25519", nil, true},
+		{"/home/u/.aws/credentials", nil, true},
+		{"/home/u/.kube/config", nil, true},
+		{"/home/u/.config/gcloud/creds.json", nil, true},
+		{"/home/u/project/id_rsa", nil, true},
+		{"/home/u/project/config", nil, false},
+		{"/home/u/project/credentials", nil, false},
+		{"/home/u/project/.env", []string{".env"}, true},
+		{"/home/u/project/secrets/prod.env", []string{"secrets/prod.env"}, false},
+		{"/home/u/project/secrets/prod.env", []string{"**/secrets/prod.env"}, true},
+		{"/home/u/project/secrets/prod.env", []string{"/home/u/project/secrets/"}, true},
+	} {
+		if got := IsDenied(tc.path, tc.extra...); got != tc.want {
+			t.Errorf("IsDenied(%q, %q) = %v, want %v", tc.path, tc.extra, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package filters
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Data-file extensions rendered with a plain "text" fence instead of the
+// extension, to avoid confusing syntax highlighters.
+var PlainFenceExt = map[string]struct{}{
+	".log": {},
+}
+
+// FenceLanguage returns the code fence language tag for path.
+func FenceLanguage(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, ok := PlainFenceExt[ext]; ok {
+		return "text"
+	}
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
+
+// Ambiguous extensions: primary language, alternate language, and the
+// content markers that indicate the alternate.
+var ambiguousExt = map[string]struct {
+	Primary   string
+	Alternate string
+	Markers   []string
+}{
+	".h": {"c", "cpp", []string{
+		"class ", "namespace ", "template<", "template <", "std::", "public:", "private:", "#include <iostream>",
+	}},
+	".m": {"objectivec", "matlab", []string{
+		"function ", "endfunction", "fprintf(", "disp(", "zeros(",
+	}},
+}
+
+// Markers that confirm the primary language and override alternate hits.
+var primaryMarkers = map[string][]string{
+	".m": {"#import", "@interface", "@implementation", "@end"},
+}
+
+// DetectLanguage returns the fence language for path, inspecting content to
+// disambiguate extensions such as .h (C or C++) and .m (Objective-C or
+// MATLAB). It falls back to the primary language when unsure.
+func DetectLanguage(path string, content string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	amb, ok := ambiguousExt[ext]
+	if !ok {
+		return FenceLanguage(path)
+	}
+	for _, m := range primaryMarkers[ext] {
+		if strings.Contains(content, m) {
+			return amb.Primary
+		}
+	}
+	for _, m := range amb.Markers {
+		if strings.Contains(content, m) {
+			return amb.Alternate
+		}
+	}
+	return amb.Primary
+}
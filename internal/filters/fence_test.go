@@ -0,0 +1,36 @@
+package filters
+
+import "testing"
+
+func TestFenceLanguage(t *testing.T) {
+	for path, want := range map[string]string{
+		"app.log":       "text",
+		"logs/APP.LOG":  "text",
+		"main.go":       "go",
+		"script.py":     "py",
+		"Makefile":      "",
+		"logs/app.logs": "logs",
+	} {
+		if got := FenceLanguage(path); got != want {
+			t.Errorf("FenceLanguage(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		path, content, want string
+	}{
+		{"list.h", "#include <stdio.h>\nstruct list { int n; };\n", "c"},
+		{"vec.h", "#pragma once\nnamespace geo {\nclass Vec {\npublic:\n};\n}\n", "cpp"},
+		{"io.h", "#include <iostream>\nvoid dump();\n", "cpp"},
+		{"empty.h", "", "c"},
+		{"View.m", "#import <UIKit/UIKit.h>\n@implementation View\n- (void)disp(x) {}\n@end\n", "objectivec"},
+		{"solve.m", "function x = solve(a)\n  disp(a);\nend\n", "matlab"},
+		{"main.c", "class x", "c"},
+	} {
+		if got := DetectLanguage(tc.path, tc.content); got != tc.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
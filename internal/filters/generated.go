@@ -0,0 +1,37 @@
+package filters
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The standard marker for generated Go code (https://go.dev/s/generatedcode).
+var generatedGoRe = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGeneratedGo reports whether path is a .go file whose header, before the
+// package clause, carries the standard generated-code comment.
+func IsGeneratedGo(path string) bool {
+	if filepath.Ext(path) != ".go" {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if generatedGoRe.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsGeneratedGo(t *testing.T) {
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		content string
+		want    bool
+	}{
+		"gen.pb.go":   {"// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage pb\n", true},
+		"crlf.go":     {"// Code generated by stringer; DO NOT EDIT.\r\npackage x\r\n", true},
+		"after.go":    {"//go:build linux\n\n// Code generated by hand. DO NOT EDIT.\npackage x\n", true},
+		"plain.go":    {"package x\n\nfunc f() {}\n", false},
+		"late.go":     {"package x\n\n// Code generated by go. DO NOT EDIT.\n", false},
+		"loose.go":    {"// code generated, do not edit\npackage x\n", false},
+		"trailing.go": {"// Code generated by x. DO NOT EDIT. Really.\npackage x\n", false},
+		"gen.txt":     {"// Code generated by x. DO NOT EDIT.\n", false},
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := IsGeneratedGo(path); got != tc.want {
+			t.Errorf("IsGeneratedGo(%s) = %v, want %v", name, got, tc.want)
+		}
+	}
+}
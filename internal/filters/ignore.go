@@ -1,6 +1,7 @@
 package filters
 
 import (
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -35,45 +36,148 @@ var DefaultIgnorePatterns = []string{
 //
 // Supports:
 //   - directory rules like "node_modules/" (match at root or ANY subdir)
-//   - anchored rules like "/node_modules" or "/build/"
+//   - anchored rules like "/node_modules", "/build/", or "doc/frotz"; as in
+//     .gitignore, a slash anywhere but at the end anchors the rule
 //   - extension rules like "*.log"
 //   - plain names like "dist" (match in any subdir)
+//   - "**" globstar rules like "docs/**/drafts/" or "**/*.spec.ts", matched
+//     segment by segment from the root ("**" spans zero or more segments;
+//     a trailing "/**" matches everything inside, not the directory itself)
+//   - "?", character classes like "[0-9]" or negated "[!0-9]", and
+//     backslash escapes like "\*" within a segment
+//
+// A rule matching a directory also matches everything beneath it. The
+// pattern is compiled on every call; see Compile and Cache.MatchPattern.
 func MatchPattern(rel, pattern string) bool {
-	rel = filepath.ToSlash(rel)
+	return Compile(pattern).Match(rel)
+}
+
+// Pattern is a MatchPattern pattern parsed once into its segments, so
+// matching it against many paths does no string surgery.
+type Pattern struct {
+	segs []patternSeg
+}
 
+// patternSeg is one slash-separated pattern segment: "**", a literal name
+// compared with ==, or a glob handed to path.Match.
+type patternSeg struct {
+	text     string
+	globstar bool
+	glob     bool
+}
+
+// Compile parses pattern for repeated matching. The zero Pattern, which an
+// empty pattern such as "/" compiles to, matches nothing.
+func Compile(pattern string) Pattern {
 	anchored := strings.HasPrefix(pattern, "/")
 	p := pattern
 	if anchored {
 		p = p[1:]
 	}
-	p = filepath.ToSlash(p)
+	p = strings.TrimSuffix(filepath.ToSlash(p), "/")
+	if p == "" {
+		return Pattern{}
+	}
 
-	// Directory rule (ends with "/")
-	if strings.HasSuffix(p, "/") {
-		dir := strings.TrimSuffix(p, "/")
-		if dir == "" {
-			return false
-		}
-		if anchored {
-			return rel == dir || strings.HasPrefix(rel, dir+"/")
+	// Only rules without a slash may start at any depth; the others,
+	// including "**" rules, are positioned explicitly.
+	if !anchored && !strings.Contains(p, "/") {
+		p = "**/" + p
+	}
+	var c Pattern
+	for _, seg := range strings.Split(gitignoreClasses(p), "/") {
+		c.segs = append(c.segs, patternSeg{
+			text:     seg,
+			globstar: seg == "**",
+			glob:     strings.ContainsAny(seg, `*?[\`),
+		})
+	}
+	return c
+}
+
+// Match reports whether the slash- or OS-separated relative path rel
+// matches, with the semantics described at MatchPattern.
+func (c Pattern) Match(rel string) bool {
+	if len(c.segs) == 0 {
+		return false
+	}
+	segs := strings.Split(filepath.ToSlash(rel), "/")
+	// The common unanchored single-name rule ("node_modules", "*.log")
+	// matches when any segment does.
+	if len(c.segs) == 2 && c.segs[0].globstar && !c.segs[1].globstar {
+		for _, seg := range segs {
+			if c.segs[1].match(seg) {
+				return true
+			}
 		}
-		// unanchored: match anywhere in the path
-		return rel == dir ||
-			strings.HasSuffix(rel, "/"+dir) ||
-			strings.HasPrefix(rel, dir+"/") ||
-			strings.Contains(rel, "/"+dir+"/")
+		return false
+	}
+	return matchGlobstar(segs, c.segs)
+}
+
+func (s patternSeg) match(name string) bool {
+	if !s.glob {
+		return s.text == name
 	}
+	ok, _ := path.Match(s.text, name)
+	return ok
+}
 
-	// Extension rule: "*.ext"
-	if strings.HasPrefix(p, "*.") {
-		return strings.HasSuffix(rel, p[1:])
+// gitignoreClasses rewrites gitignore's negated classes "[!...]" into the
+// "[^...]" form understood by path.Match, leaving escaped brackets alone.
+func gitignoreClasses(p string) string {
+	if !strings.Contains(p, "[!") {
+		return p
 	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == '\\' && i+1 < len(p):
+			b.WriteByte(p[i])
+			i++
+			b.WriteByte(p[i])
+		case p[i] == '[' && i+1 < len(p) && p[i+1] == '!':
+			b.WriteString("[^")
+			i++
+		default:
+			b.WriteByte(p[i])
+		}
+	}
+	return b.String()
+}
 
-	// Anchored plain rule
-	if anchored {
-		return rel == p || strings.HasPrefix(rel, p+"/")
+// matchGlobstar matches path segments against pattern segments. A match
+// on any leading run of the segments counts, so a matched directory also
+// covers everything beneath it.
+func matchGlobstar(segs []string, pat []patternSeg) bool {
+	for k := 1; k <= len(segs); k++ {
+		if matchSegments(segs[:k], pat) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Unanchored plain rule: match anywhere
-	return rel == p || strings.HasSuffix(rel, "/"+p) || strings.Contains(rel, "/"+p+"/")
+// matchSegments matches path segments against pattern segments, where "**"
+// consumes zero or more segments (one or more when it ends the pattern) and
+// other segments use path.Match globbing.
+func matchSegments(segs []string, pat []patternSeg) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0].globstar {
+		if len(pat) == 1 {
+			return len(segs) > 0
+		}
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(segs[i:], pat[1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 || !pat[0].match(segs[0]) {
+		return false
+	}
+	return matchSegments(segs[1:], pat[1:])
 }
@@ -0,0 +1,115 @@
+package filters
+
+import "testing"
+
+type matchCase struct {
+	pattern string
+	path    string
+	want    bool
+}
+
+func checkMatches(t *testing.T, cases []matchCase) {
+	t.Helper()
+	for _, tc := range cases {
+		if got := MatchPattern(tc.path, tc.pattern); got != tc.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tc.path, tc.pattern, got, tc.want)
+		}
+		if got := Compile(tc.pattern).Match(tc.path); got != tc.want {
+			t.Errorf("Compile(%q).Match(%q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestMatchPatternGlobstar(t *testing.T) {
+	checkMatches(t, []matchCase{
+		// "**" in the middle spans zero or more directories.
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/b/c.txt", true},
+		{"a/**/b", "a/xb", false},
+		{"a/**/b", "x/a/b", false},
+		{"src/**/test", "src/pkg/test/x_test.go", true},
+
+		// A leading "**/" matches at any depth, including the root.
+		{"**/foo", "foo", true},
+		{"**/foo", "x/foo", true},
+		{"**/foo", "x/y/foo/bar.txt", true},
+		{"**/foo", "foobar", false},
+		{"**/*.spec.ts", "a.spec.ts", true},
+		{"**/*.spec.ts", "src/x/a.spec.ts", true},
+		{"**/*.spec.ts", "src/a.ts", false},
+
+		// A trailing "/**" matches everything inside, not the directory.
+		{"logs/**", "logs/a.log", true},
+		{"logs/**", "logs/2024/a.log", true},
+		{"logs/**", "logs", false},
+		{"logs/**", "x/logs/a.log", false},
+		{"docs/**/drafts/", "docs/drafts/a.md", true},
+		{"docs/**/drafts/", "docs/v1/drafts/a.md", true},
+	})
+}
+
+func TestMatchPatternAnchoring(t *testing.T) {
+	checkMatches(t, []matchCase{
+		// Names without a slash match at any depth.
+		{"frotz", "frotz", true},
+		{"frotz", "a/b/frotz", true},
+		{"frotz", "a/frotz/x.txt", true},
+		{"*.log", "c.log", true},
+		{"*.log", "a/b/c.log", true},
+		{"*.log", "c.logs", false},
+		{"node_modules/", "web/node_modules/x/index.js", true},
+
+		// A slash at the start or in the middle anchors the rule.
+		{"doc/frotz", "doc/frotz", true},
+		{"doc/frotz", "doc/frotz/x.txt", true},
+		{"doc/frotz", "a/doc/frotz", false},
+		{"/doc/frotz", "doc/frotz", true},
+		{"/doc/frotz", "a/doc/frotz", false},
+		{"doc/frotz/", "doc/frotz/x.txt", true},
+		{"doc/frotz/", "a/doc/frotz/x.txt", false},
+		{"src/*.go", "src/a.go", true},
+		{"src/*.go", "src/x/a.go", false},
+		{"src/*.go", "lib/src/a.go", false},
+		{"/build/", "build/out.o", true},
+		{"/build/", "src/build/out.o", false},
+		{"/main.go", "main.go", true},
+		{"/main.go", "cmd/main.go", false},
+
+		// Empty patterns match nothing.
+		{"", "a", false},
+		{"/", "a", false},
+	})
+}
+
+func TestMatchPatternClasses(t *testing.T) {
+	checkMatches(t, []matchCase{
+		// "?" matches one character other than a slash.
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file.txt", false},
+		{"file?.txt", "file12.txt", false},
+		{"a?b", "a/b", false},
+
+		// Ranges and sets.
+		{"log[0-9].txt", "log7.txt", true},
+		{"log[0-9].txt", "logx.txt", false},
+		{"*.[ch]", "src/main.c", true},
+		{"*.[ch]", "src/main.h", true},
+		{"*.[ch]", "src/main.o", false},
+
+		// Negated classes, in gitignore's "[!...]" and the "[^...]" form.
+		{"v[!0-9]", "vx", true},
+		{"v[!0-9]", "v1", false},
+		{"v[^0-9]", "vx", true},
+		{"v[^0-9]", "v1", false},
+
+		// Escapes match the character literally.
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+		{`what\?`, "what?", true},
+		{`what\?`, "whats", false},
+		{`\[draft\].md`, "[draft].md", true},
+		{`\[draft\].md`, "d.md", false},
+	})
+}
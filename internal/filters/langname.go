@@ -0,0 +1,49 @@
+package filters
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Display names for the Summary's per-language breakdown. Extensions of one
+// language share a name so they are counted together.
+var LanguageNames = map[string]string{
+	".go": "Go", ".mod": "Go module", ".sum": "Go module", ".tmpl": "Go template",
+	".md": "Markdown", ".mdx": "Markdown", ".rst": "reStructuredText", ".txt": "Text",
+	".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript", ".jsx": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript",
+	".py": "Python", ".pyi": "Python",
+	".rb": "Ruby", ".pl": "Perl", ".pm": "Perl", ".php": "PHP", ".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin", ".scala": "Scala",
+	".c": "C", ".h": "C", ".cpp": "C++", ".cc": "C++", ".cxx": "C++", ".hpp": "C++", ".hh": "C++",
+	".m": "Objective-C", ".mm": "Objective-C", ".swift": "Swift", ".cs": "C#", ".fs": "F#",
+	".rs": "Rust", ".hs": "Haskell", ".ml": "OCaml", ".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang",
+	".lua": "Lua", ".r": "R", ".jl": "Julia",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".fish": "Shell", ".ps1": "PowerShell",
+	".html": "HTML", ".htm": "HTML", ".css": "CSS", ".scss": "SCSS", ".sass": "Sass", ".less": "Less",
+	".vue": "Vue", ".svelte": "Svelte",
+	".json": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML", ".ini": "INI",
+	".sql": "SQL", ".graphql": "GraphQL", ".gql": "GraphQL", ".proto": "Protocol Buffers",
+	".tf": "Terraform", ".tfvars": "Terraform",
+}
+
+// LanguageName returns the Summary breakdown bucket for path: a language
+// name, the bare extension for unlisted ones, the file name for
+// well-known extensionless files such as Makefile, the language of an
+// extensionless script's shebang, or "misc".
+func LanguageName(path string) string {
+	base := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(base))
+	if ext == "" || ext == base {
+		if _, ok := TextFilenames[base]; ok {
+			return base
+		}
+		if name, ok := LanguageNames[hintExt(path)]; ok {
+			return name
+		}
+		return "misc"
+	}
+	if name, ok := LanguageNames[ext]; ok {
+		return name
+	}
+	return ext
+}
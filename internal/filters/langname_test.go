@@ -0,0 +1,33 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLanguageName(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"deploy": "#!/usr/bin/env python3\n",
+		"notes":  "plain\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, want := range map[string]string{
+		"main.go":                    "Go",
+		"README.MD":                  "Markdown",
+		"api.proto":                  "Protocol Buffers",
+		"data.xyz":                   ".xyz",
+		"Makefile":                   "Makefile",
+		".gitignore":                 ".gitignore",
+		filepath.Join(dir, "deploy"): "Python",
+		filepath.Join(dir, "notes"):  "misc",
+	} {
+		if got := LanguageName(path); got != want {
+			t.Errorf("LanguageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package filters
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Interpreters recognised in a "#!" line, mapped to the extension their
+// scripts would usually carry. The extension picks the fence tag and the
+// Summary bucket, so a "deploy" script with "#!/usr/bin/env python3" is
+// treated like deploy.py.
+var ShebangInterpreters = map[string]string{
+	"python": ".py", "bash": ".bash", "sh": ".sh", "zsh": ".zsh",
+	"node": ".js", "ruby": ".rb", "perl": ".pl",
+}
+
+// shebangExt returns the extension for the interpreter named by line, or
+// "" when line is not a shebang for a known interpreter. It looks through
+// env (with or without -S) and strips version suffixes such as python3.12.
+func shebangExt(line string) string {
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := filepath.Base(fields[0])
+	if name == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return ""
+		}
+		name = filepath.Base(fields[0])
+	}
+	return ShebangInterpreters[strings.TrimRight(name, "0123456789.")]
+}
+
+// hintExt returns the extension implied by the shebang of an extensionless
+// file, or "" for files with an extension, without a shebang, or with an
+// unknown interpreter.
+func hintExt(path string) string {
+	base := filepath.Base(path)
+	if ext := filepath.Ext(base); ext != "" && ext != base {
+		return ""
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	line, _, _ := bufio.NewReaderSize(f, 256).ReadLine()
+	return shebangExt(string(line))
+}
+
+// LanguageHint returns the fence language implied by the shebang line of
+// an extensionless script, e.g. "py" for "#!/usr/bin/env python3", or "".
+func LanguageHint(path string) string {
+	return strings.TrimPrefix(hintExt(path), ".")
+}
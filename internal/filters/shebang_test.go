@@ -0,0 +1,56 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShebangExt(t *testing.T) {
+	for line, want := range map[string]string{
+		"#!/usr/bin/env python3":           ".py",
+		"#!/usr/bin/python3.12 -u":         ".py",
+		"#!/bin/bash":                      ".bash",
+		"#! /bin/sh -e":                    ".sh",
+		"#!/usr/bin/env -S node --harmony": ".js",
+		"#!/usr/bin/env ruby":              ".rb",
+		"#!/usr/bin/perl -w":               ".pl",
+		"#!/usr/bin/env":                   "",
+		"#!/usr/bin/env lua":               "",
+		"# not a shebang":                  "",
+		"":                                 "",
+	} {
+		if got := shebangExt(line); got != want {
+			t.Errorf("shebangExt(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestLanguageHint(t *testing.T) {
+	dir := t.TempDir()
+	// Invalid UTF-8 that the sniff alone would call binary, so only the
+	// shebang can make these text.
+	tail := "\n\xff\xfe\x01\x02\x03\x04\x05\x06"
+	for _, tc := range []struct {
+		name, content, lang string
+		text                bool
+	}{
+		{"deploy", "#!/usr/bin/env python3" + tail, "py", true},
+		{"build", "#!/bin/bash" + tail, "bash", true},
+		{"serve", "#!/usr/bin/env node" + tail, "js", true},
+		{"blob", "\x00\x01" + tail, "", false},
+		// A real extension wins over the shebang.
+		{"tool.txt", "#!/usr/bin/env ruby\n", "", true},
+	} {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := LanguageHint(path); got != tc.lang {
+			t.Errorf("LanguageHint(%s) = %q, want %q", tc.name, got, tc.lang)
+		}
+		if got := IsTextFile(path); got != tc.text {
+			t.Errorf("IsTextFile(%s) = %v, want %v", tc.name, got, tc.text)
+		}
+	}
+}
@@ -9,7 +9,8 @@ import (
 	"unicode/utf8"
 )
 
-// Broad text/code extensions
+// Broad text/code extensions. Keys are lowercase; lookups lowercase the
+// extension first, so ".R" and ".RMD" match ".r" and ".rmd".
 var TextExt = map[string]struct{}{
 	// docs & markup
 	".txt": {}, ".md": {}, ".mdx": {}, ".rst": {}, ".adoc": {}, ".asciidoc": {},
@@ -45,6 +46,9 @@ var TextExt = map[string]struct{}{
 	// ruby
 	".rb": {}, ".erb": {}, ".rake": {}, ".gemspec": {},
 
+	// perl
+	".pl": {}, ".pm": {},
+
 	// php
 	".php": {}, ".phtml": {}, ".php3": {}, ".php4": {}, ".php5": {}, ".php7": {}, ".php8": {},
 
@@ -96,7 +100,7 @@ var TextExt = map[string]struct{}{
 	".plantuml": {}, ".puml": {}, ".dot": {}, ".gv": {}, ".mermaid": {}, ".mmd": {},
 
 	// data science
-	".r": {}, ".R": {}, ".Rmd": {}, ".qmd": {}, ".jl": {},
+	".r": {}, ".rmd": {}, ".qmd": {}, ".jl": {},
 }
 
 // Well-known text filenames (no extension)
@@ -168,7 +172,9 @@ func isProbablyTextFile(path string) bool {
 	return float64(printable)/float64(len(s)) >= 0.95
 }
 
-// Exported helper used by main
+// Exported helper used by main. Extensionless scripts with a known
+// shebang count as text even when sniffing is unsure. Each call sniffs
+// the file afresh; see Cache.IsTextFile.
 func IsTextFile(path string) bool {
-	return hasTextyName(path) || isProbablyTextFile(path)
+	return hasTextyName(path) || isProbablyTextFile(path) || hintExt(path) != ""
 }
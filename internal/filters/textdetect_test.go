@@ -0,0 +1,31 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsTextFileExtensionCase(t *testing.T) {
+	dir := t.TempDir()
+	// Invalid UTF-8 that the sniff alone would call binary, so only the
+	// extension can make these text.
+	content := []byte("x <- c(1, 2)\n\xff\xfe\x01\x02\x03\x04\x05\x06")
+	for name, want := range map[string]bool{
+		"a.r":      true,
+		"b.R":      true,
+		"c.RMD":    true,
+		"d.Rmd":    true,
+		"e.GO":     true,
+		"f.bin":    false,
+		"Makefile": true,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := IsTextFile(path); got != want {
+			t.Errorf("IsTextFile(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package rank scores files by how useful they are to read first when
+// getting to know a repository.
+package rank
+
+import (
+	"path"
+	"strings"
+)
+
+// Entrypoints are file names that usually start a program or define a
+// package's public surface.
+var Entrypoints = map[string]bool{
+	"main.go": true, "main.py": true, "__main__.py": true, "app.py": true, "manage.py": true,
+	"index.js": true, "index.ts": true, "index.jsx": true, "index.tsx": true, "index.mjs": true,
+	"main.js": true, "main.ts": true, "app.js": true, "app.ts": true, "server.js": true, "server.ts": true,
+	"main.rs": true, "lib.rs": true, "main.c": true, "main.cpp": true, "Main.java": true,
+	"Program.cs": true, "main.swift": true, "main.kt": true,
+}
+
+// Overviews are files that describe the project or its dependencies.
+var Overviews = map[string]bool{
+	"README.md": true, "README": true, "README.rst": true, "README.txt": true,
+	"go.mod": true, "package.json": true, "Cargo.toml": true, "pyproject.toml": true,
+	"setup.py": true, "pom.xml": true, "build.gradle": true, "Makefile": true, "Dockerfile": true,
+}
+
+// Score rates a file from its slash-separated path relative to the root
+// and the number of other files that mention it (inbound). Higher is more
+// important:
+//
+//   - +10 for an entrypoint such as main.go or index.ts, +8 for an
+//     overview such as README.md or go.mod
+//   - +3 per inbound mention, up to +15
+//   - -2 per directory level below the root
+//   - -5 for tests: *_test.go, *.test.*, *.spec.*, test_*.py, or anything
+//     under a test, tests, __tests__, or testdata directory
+//
+// The weights are a heuristic meant for ordering, not an absolute measure.
+func Score(rel string, inbound int) int {
+	name := path.Base(rel)
+	score := 0
+	switch {
+	case Entrypoints[name]:
+		score += 10
+	case Overviews[name]:
+		score += 8
+	}
+	score += 3 * min(inbound, 5)
+	score -= 2 * strings.Count(rel, "/")
+	if isTest(rel) {
+		score -= 5
+	}
+	return score
+}
+
+func isTest(rel string) bool {
+	name := path.Base(rel)
+	if strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py") {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		switch dir {
+		case "test", "tests", "__tests__", "testdata":
+			return true
+		}
+	}
+	return false
+}
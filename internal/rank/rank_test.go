@@ -0,0 +1,26 @@
+package rank
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	for _, tc := range []struct {
+		rel     string
+		inbound int
+		want    int
+	}{
+		{"main.go", 0, 10},
+		{"README.md", 0, 8},
+		{"util.go", 0, 0},
+		{"cmd/app/main.go", 0, 6},
+		{"internal/deep/nested/helper.go", 0, -6},
+		{"util.go", 2, 6},
+		{"util.go", 9, 15},
+		{"util_test.go", 0, -5},
+		{"pkg/testdata/x.go", 0, -9},
+		{"web/app.spec.ts", 0, -7},
+	} {
+		if got := Score(tc.rel, tc.inbound); got != tc.want {
+			t.Errorf("Score(%q, %d) = %d, want %d", tc.rel, tc.inbound, got, tc.want)
+		}
+	}
+}
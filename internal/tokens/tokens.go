@@ -0,0 +1,11 @@
+// Package tokens estimates how many LLM tokens a text will use.
+package tokens
+
+import "unicode/utf8"
+
+// Estimate approximates the number of tokens in s at one token per four
+// characters, rounded up. That tracks common BPE tokenizers closely enough
+// on source code and English prose for context budgeting.
+func Estimate(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
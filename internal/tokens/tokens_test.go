@@ -0,0 +1,20 @@
+package tokens
+
+import "testing"
+
+func TestEstimate(t *testing.T) {
+	for s, want := range map[string]int{
+		"":                   0,
+		"a":                  1,
+		"abcd":               1,
+		"abcde":              2,
+		"package main\n":     4,
+		"héllo wörld":        3,
+		"日本語のテキスト":           2,
+		"func main() {}\n\n": 4,
+	} {
+		if got := Estimate(s); got != want {
+			t.Errorf("Estimate(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
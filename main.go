@@ -1,501 +1,546 @@
+// Command myreporeader prints a repository as a single Markdown document.
+// See the reporeader package for the library API.
 package main
 
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
 	"strings"
-	"unicode/utf8"
+	"time"
 
-	filters "github.com/whoisrgxu/myreporeader/internal/filters"
+	"github.com/whoisrgxu/myreporeader/reporeader"
 )
 
-type Directory struct {
-	ParentPath string
-	Name       string
-	Indent     string
+// cliOptions adds the flags that only concern the command itself to the
+// library options.
+type cliOptions struct {
+	reporeader.Options
+	CPUProfile string
+	MemProfile string
+	JSONSchema bool
+	Clipboard  bool
 }
 
-type GitInfo struct {
-	Hash   string
-	Branch string
-	Author string
-	Date   string
-}
-
-// Per-directory .gitignore rules
-var gitignoreRules = map[string][]string{}
+// ---------------- Flags ----------------
 
-// ---------------- .gitignore handling ----------------
+// printUsage writes the usage line and flag defaults to w.
+func printUsage(w io.Writer) {
+	fs := newFlagSet(&cliOptions{})
+	fs.SetOutput(w)
+	fs.Usage()
+}
 
-func loadGitignores(root string) {
-	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+// newFlagSet binds every named flag to a field of o.
+func newFlagSet(o *cliOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("myreporeader", flag.ContinueOnError)
+	fs.StringVar(&o.Output, "o", "", "write Markdown output to `file` instead of stdout")
+	fs.Func("include", "only include files with these comma-separated `.ext`s (repeatable)", func(v string) error {
+		if o.Include == nil {
+			o.Include = map[string]struct{}{}
+		}
+		for _, ext := range parseExtList(v) {
+			o.Include[ext] = struct{}{}
+		}
+		return nil
+	})
+	fs.Func("include-glob", "only include paths matching these comma-separated .gitignore-style `patterns` (repeatable)", func(v string) error {
+		for _, pat := range strings.Split(v, ",") {
+			if pat = strings.TrimSpace(pat); pat != "" {
+				o.IncludeGlob = append(o.IncludeGlob, pat)
+			}
+		}
+		return nil
+	})
+	fs.Func("include-regex", "only include files whose slash-separated relative path matches the Go `regexp` (repeatable)", func(v string) error {
+		re, err := compileRegex(v)
 		if err != nil {
-			return nil
-		}
-		if d.IsDir() {
-			gitignorePath := filepath.Join(path, ".gitignore")
-			data, err := os.ReadFile(gitignorePath)
-			if err == nil {
-				lines := strings.Split(string(data), "\n")
-				for _, line := range lines {
-					line = strings.TrimSpace(line)
-					if line == "" || strings.HasPrefix(line, "#") {
-						continue
-					}
-					gitignoreRules[path] = append(gitignoreRules[path], line)
-				}
+			return err
+		}
+		o.IncludeRegex = append(o.IncludeRegex, re)
+		return nil
+	})
+	fs.Func("exclude-regex", "leave out files whose slash-separated relative path matches the Go `regexp` (repeatable)", func(v string) error {
+		re, err := compileRegex(v)
+		if err != nil {
+			return err
+		}
+		o.ExcludeRegex = append(o.ExcludeRegex, re)
+		return nil
+	})
+	fs.StringVar(&o.Since, "since", "", "only include files changed between git `ref` and HEAD (git diff ref...HEAD)")
+	fs.BoolVar(&o.StructureOnly, "structure-only", false, "print only the header, git info, and structure tree, reading no file contents")
+	fs.BoolVar(&o.NoStructure, "no-structure", false, "leave the structure tree out of the document")
+	fs.StringVar(&o.Structure, "structure", "full", "show the `full` structure, or only the changed files' paths (requires -since or -modified-since)")
+	fs.Func("modified-since", "only include files modified after `time`: a duration before now such as 7d or 48h, or an RFC 3339 date", func(v string) error {
+		t, err := parseModifiedSince(v, time.Now())
+		o.ModifiedSince = t
+		return err
+	})
+	fs.StringVar(&o.Manifest, "manifest", "", "only include the paths listed in `file`, one per line relative to the target")
+	fs.BoolVar(&o.ListBinaries, "list-binaries", false, "list files skipped as binary, with their sizes, after the file contents")
+	fs.Func("ignore-files", "read per-directory ignore rules from these comma-separated file `names` instead of just .gitignore (repeatable)", func(v string) error {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				o.IgnoreFiles = append(o.IgnoreFiles, name)
 			}
 		}
 		return nil
 	})
-}
-
-// Check ignore using .gitignore (walking up to root) + default patterns.
-func isIgnored(path string, root string) bool {
-	abs, _ := filepath.Abs(path)
-	abs = filepath.Clean(abs)
-
-	// 1) .gitignore rules from the file's dir up to root
-	dir := filepath.Dir(abs)
-	for {
-		patterns := gitignoreRules[dir]
-		relFromDir, _ := filepath.Rel(dir, abs)
-		relFromDir = filepath.ToSlash(relFromDir)
-
-		for _, pat := range patterns {
-			pat = strings.TrimSpace(pat)
-			if pat == "" || strings.HasPrefix(pat, "#") {
-				continue
+	fs.StringVar(&o.IgnoreFile, "ignore-file", "", "ignore paths matching the .gitignore-style patterns in `file`, relative to the target")
+	fs.BoolVar(&o.Force, "force", false, "print a single-file target even if ignore rules exclude it")
+	fs.Func("exclude", "ignore paths matching these comma-separated .gitignore-style `patterns` (repeatable)", func(v string) error {
+		for _, pat := range strings.Split(v, ",") {
+			if pat = strings.TrimSpace(pat); pat != "" {
+				o.Exclude = append(o.Exclude, pat)
 			}
-			if filters.MatchPattern(relFromDir, pat) {
-				return true
+		}
+		return nil
+	})
+	fs.BoolVar(&o.UTF8Replace, "utf8-replace", false, "print invalid UTF-8 files with U+FFFD replacements instead of skipping them")
+	fs.BoolVar(&o.ChunkByDirectory, "chunk-by-directory", false, "write one document per top-level directory (requires -o or -output-dir)")
+	fs.Func("plain-fence", "render these comma-separated `.ext`s in a plain text fence", func(v string) error {
+		o.PlainFence = append(o.PlainFence, parseExtList(v)...)
+		return nil
+	})
+	fs.Func("text-ext", "treat files with these comma-separated `.ext`s as text", func(v string) error {
+		o.TextExt = append(o.TextExt, parseExtList(v)...)
+		return nil
+	})
+	fs.Func("text-name", "treat files with these comma-separated `names` (e.g. Brewfile) as text", func(v string) error {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				o.TextNames = append(o.TextNames, name)
 			}
 		}
+		return nil
+	})
+	fs.BoolVar(&o.StrictGitattributes, "respect-binary-gitattributes-only", false, "also let .gitattributes text attributes force a file to be treated as text")
+	fs.BoolVar(&o.EOLGitattributes, "respect-eol-gitattributes", false, "print the contents of files with a .gitattributes eol attribute with LF line endings, as git stores them, and count their lines the same way")
+	fs.IntVar(&o.CombineSmall, "combine-small", 0, "combine files shorter than `N` lines into one fenced block")
+	fs.StringVar(&o.OutputDir, "output-dir", "", "write output into `dir` and exclude it from the scan")
+	fs.BoolVar(&o.Incremental, "incremental", false, "keep an index in -output-dir and reuse unchanged files from the previous run")
+	fs.IntVar(&o.MaxConcurrency, "max-concurrency", 0, "read, classify, and count files with up to `N` workers")
+	fs.IntVar(&o.MaxConcurrency, "jobs", 0, "same as -max-concurrency `N`")
+	fs.BoolVar(&o.Progress, "progress", stderrIsTerminal(), "show running file and line counts on stderr (default on when stderr is a terminal)")
+	fs.BoolVar(&o.Hashes, "hashes", false, "add the SHA-256 of each printed file to its header")
+	fs.BoolVar(&o.IncludeFileMetadata, "include-file-metadata", false, "list each file's size, lines, modification time, mode, and language before its contents")
+	fs.BoolVar(&o.HygieneNotes, "hygiene-notes", false, "flag trailing whitespace and missing final newlines in file headers")
+	fs.BoolVar(&o.TrimTrailingSpace, "trim-trailing-whitespace", false, "remove trailing spaces and tabs from every printed line")
+	fs.Func("deny", "never read files matching these comma-separated `patterns`", func(v string) error {
+		for _, pat := range strings.Split(v, ",") {
+			if pat = strings.TrimSpace(pat); pat != "" {
+				o.Deny = append(o.Deny, pat)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&o.PrintIgnored, "print-ignored", false, "list ignored paths and the matching rule after the Summary")
+	fs.BoolVar(&o.GitDateRelative, "git-date-relative", false, "show the commit date relative to now")
+	fs.BoolVar(&o.GitStatus, "git-status", false, "annotate file headers with their git working-tree status")
+	fs.BoolVar(&o.IncludeUntracked, "include-untracked", false, "in a git repository, also count files that are untracked but not ignored")
+	fs.BoolVar(&o.DedupHeaders, "dedup-headers", false, "print a leading comment block shared by several files (e.g. a license) once instead of in each file")
+	fs.BoolVar(&o.LinkReferences, "link-references", false, "after each file, add a References: line linking to the other printed files whose paths it mentions; the mentions themselves stay plain text inside the code block")
+	fs.BoolVar(&o.Rank, "rank", false, "order file contents by estimated importance (entrypoints, shallow and often-mentioned files first)")
+	fs.BoolVar(&o.TOC, "toc", false, "insert a table of contents before File Contents")
+	fs.BoolVar(&o.NoMkdir, "no-mkdir", false, "fail instead of creating missing output directories")
+	fs.BoolVar(&o.GoDepOrder, "go-dep-order", false, "order Go file contents by package, imported packages before their importers and main last")
+	fs.BoolVar(&o.GoOrigin, "go-origin", false, "summarize first-party vs third-party Go files")
+	fs.BoolVar(&o.ExcludeGeneratedGo, "exclude-generated-go", false, "skip Go files marked \"Code generated ... DO NOT EDIT.\"")
+	fs.BoolVar(&o.DirStats, "dir-stats", false, "annotate directories in the structure with recursive file and line counts")
+	fs.BoolVar(&o.CollapseSimilarDirs, "collapse-similar-dirs", false, "show sibling directories with identical layouts once, with an (xN) count")
+	fs.Func("max-depth", "read directories at most `N` levels below the root's children (0: the root's entries only)", func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		o.MaxDepth = &n
+		return nil
+	})
+	fs.StringVar(&o.StructureFormat, "structure-format", "tree", "render the structure as `tree` or mermaid")
+	fs.StringVar(&o.SummaryPosition, "summary-position", "bottom", "print the Summary at the `top` (after Git Info) or bottom")
+	fs.BoolVar(&o.CountBlankLines, "count-blank-lines", false, "list code, comment, and blank line totals separately in the Summary")
+	fs.BoolVar(&o.PerDirSummary, "per-dir-summary", false, "add a table of file and line counts per top-level directory to the Summary")
+	fs.IntVar(&o.PerDirDepth, "per-dir-depth", 1, "group -per-dir-summary rows by the first `N` directory levels")
+	fs.BoolVar(&o.ASCII, "ascii", false, "draw the structure tree with ASCII |-- and `-- instead of box-drawing characters")
+	fs.IntVar(&o.Indent, "indent", 4, "indent each structure tree level by `N` columns (at least 2)")
+	fs.BoolVar(&o.ShowMtime, "show-mtime", false, "annotate structure entries with their modification time")
+	fs.BoolVar(&o.Hidden, "hidden", false, "include dotfiles and dot-directories such as .github/ (ignore rules still apply; .git never shows)")
+	fs.BoolVar(&o.FollowSymlinks, "follow-symlinks", false, "descend into symlinked directories, entering each resolved directory once")
+	fs.IntVar(&o.MaxPathLength, "max-path-length", 0, "shorten rendered paths longer than `N` characters to first/.../name")
+	fs.BoolVar(&o.NormalizePathsPOSIX, "normalize-paths-posix", false, "render every path with forward slashes and a lowercase drive letter")
+	fs.StringVar(&o.Format, "format", "markdown", "write the document as `markdown`, json, or html")
+	fs.IntVar(&o.MaxLines, "max-lines", 0, "print only the first `N` lines of each file, noting how many were left out")
+	fs.IntVar(&o.MaxOutputLines, "max-output-lines", 0, "stop writing after `N` lines of output and note the truncation")
+	fs.Func("max-file-size", "skip the contents of files larger than `size` (e.g. 200KB, 1.5MB)", func(v string) error {
+		n, err := parseSize(v)
+		o.MaxFileSize = n
+		return err
+	})
+	fs.Func("max-output-size", "stop adding file contents once the output reaches `size` (e.g. 5MB); the Summary still prints", func(v string) error {
+		n, err := parseSize(v)
+		o.MaxOutputSize = n
+		return err
+	})
+	fs.Func("split-size", "with -o, write the document as numbered parts of at most `size` each (e.g. 100KB), breaking only between files", func(v string) error {
+		n, err := parseSize(v)
+		o.SplitSize = n
+		return err
+	})
+	fs.Func("exclude-by-size-range", "leave out files whose size is in `MIN-MAX` (e.g. 0-100, 1MB-, -2KB; repeatable)", func(v string) error {
+		r, err := parseSizeRange(v)
+		o.ExcludeSizeRange = append(o.ExcludeSizeRange, r)
+		return err
+	})
+	fs.IntVar(&o.LargeFileLines, "large-file-lines", 5000, "mark text files longer than `N` lines in the structure (0 to disable)")
+	fs.BoolVar(&o.LineNumbers, "line-numbers", false, "prefix each printed line with its line number")
+	fs.IntVar(&o.SplitLargeFiles, "split-large-files", 0, "split file contents into fenced parts of at most `N` lines")
+	fs.IntVar(&o.MaxTokens, "max-tokens", 0, "stop adding file contents once they reach about `N` tokens")
+	fs.Func("hexdump-ext", "render files with these comma-separated `.ext`s as a hex dump", func(v string) error {
+		o.HexdumpExt = append(o.HexdumpExt, parseExtList(v)...)
+		return nil
+	})
+	fs.Var(newSizeValue(64<<10, &o.MaxBinarySize), "max-binary-size", "dump at most `size` bytes of each -hexdump-ext file (0 for no limit)")
+	fs.BoolVar(&o.Watch, "watch", false, "keep running and regenerate the output whenever files change")
+	fs.DurationVar(&o.WatchDebounce, "watch-debounce", 500*time.Millisecond, "poll interval; changes must settle this long before regenerating")
+	fs.BoolVar(&o.Clipboard, "clipboard", false, "copy the document to the system clipboard instead of writing it to stdout")
+	fs.BoolVar(&o.JSONSchema, "json-schema", false, "print the JSON Schema of the -format json output and exit")
+	fs.StringVar(&o.CPUProfile, "profile", "", "write a CPU profile to `file`")
+	fs.StringVar(&o.MemProfile, "memprofile", "", "write a heap profile to `file`")
+
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: myreporeader [flags] <path>")
+		fmt.Fprintln(fs.Output(), "       ... | myreporeader [flags] -    (read file paths from stdin)")
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-		if dir == root {
-			break
+// parseArgs parses command-line arguments (without the program name).
+// Flags and the path may appear in any order. The legacy trailing
+// "o outputfile" form is still accepted. Errors are returned, not printed.
+func parseArgs(args []string) (cliOptions, error) {
+	var o cliOptions
+	fs := newFlagSet(&o)
+	fs.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return o, err
 		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
+		args = fs.Args()
+		if len(args) == 0 {
 			break
 		}
-		dir = parent
+		positional = append(positional, args[0])
+		args = args[1:]
 	}
 
-	// 2) Default cross-ecosystem patterns relative to repo root
-	relFromRoot, _ := filepath.Rel(root, abs)
-	relFromRoot = filepath.ToSlash(relFromRoot)
-	for _, pat := range filters.DefaultIgnorePatterns {
-		if filters.MatchPattern(relFromRoot, pat) {
-			return true
+	if n := len(positional); n >= 3 && positional[n-2] == "o" {
+		if o.Output != "" {
+			return o, errors.New("output file given twice (-o and o)")
 		}
+		o.Output = positional[n-1]
+		positional = positional[:n-2]
+	}
+	switch len(positional) {
+	case 0:
+	case 1:
+		o.Path = positional[0]
+	default:
+		return o, fmt.Errorf("unexpected arguments: %s", strings.Join(positional[1:], " "))
 	}
+	return o, nil
+}
 
-	return false
+// parseSize parses a byte count with an optional B, KB, MB, or GB suffix
+// (case-insensitive, binary multiples), e.g. "200KB" or "1.5MB".
+func parseSize(v string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(v))
+	mult := 1.0
+	for _, u := range []struct {
+		suffix string
+		mult   float64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", v)
+	}
+	return int64(n * mult), nil
 }
 
-// ---------------- Git helpers (for accurate summary) ----------------
+// sizeValue is a flag.Value holding a byte count in the parseSize format.
+type sizeValue int64
 
-func isGitRepo(root string) bool {
-	_, err := os.Stat(filepath.Join(root, ".git"))
-	return err == nil
+// newSizeValue sets *p to val, its default, and returns it as a sizeValue.
+func newSizeValue(val int64, p *int64) *sizeValue {
+	*p = val
+	return (*sizeValue)(p)
 }
 
-func listGitTrackedFiles(root string) ([]string, error) {
-	cmd := exec.Command("git", "-C", root, "ls-files", "-z")
-	out, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-	parts := bytes.Split(out, []byte{0})
-	files := make([]string, 0, len(parts))
-	for _, p := range parts {
-		if len(p) == 0 {
-			continue
+func (s *sizeValue) Set(v string) error {
+	n, err := parseSize(v)
+	*s = sizeValue(n)
+	return err
+}
+
+// String renders the size with the largest unit that divides it, e.g.
+// "64KB", as printed for the flag default.
+func (s *sizeValue) String() string {
+	n := int64(*s)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if n != 0 && n%u.mult == 0 {
+			return fmt.Sprintf("%d%s", n/u.mult, u.suffix)
 		}
-		files = append(files, filepath.Join(root, string(p)))
 	}
-	return files, nil
+	return strconv.FormatInt(n, 10)
 }
 
-func countFilesAndLinesGit(root string) (int, int, error) {
-	files, err := listGitTrackedFiles(root)
-	if err != nil {
-		return 0, 0, err
+// parseSizeRange parses an inclusive "MIN-MAX" range of sizes in the
+// parseSize format. Either end may be left out: "1MB-" has no upper bound
+// and "-100" starts at zero.
+func parseSizeRange(v string) (reporeader.SizeRange, error) {
+	r := reporeader.SizeRange{Max: -1}
+	lo, hi, ok := strings.Cut(v, "-")
+	if !ok || strings.TrimSpace(lo) == "" && strings.TrimSpace(hi) == "" {
+		return r, fmt.Errorf("invalid size range %q (want MIN-MAX, MIN-, or -MAX)", v)
 	}
-
-	fileCount := 0
-	lineCount := 0
-
-	for _, f := range files {
-		if isIgnored(f, root) {
-			continue
+	var err error
+	if strings.TrimSpace(lo) != "" {
+		if r.Min, err = parseSize(lo); err != nil {
+			return r, err
 		}
-		if !filters.IsTextFile(f) {
-			continue
+	}
+	if strings.TrimSpace(hi) != "" {
+		if r.Max, err = parseSize(hi); err != nil {
+			return r, err
 		}
-		lines, err := countLinesInFile(f)
-		if err != nil {
-			continue
+		if r.Max < r.Min {
+			return r, fmt.Errorf("invalid size range %q (MAX is below MIN)", v)
 		}
-		fileCount++
-		lineCount += lines
-	}
-	return fileCount, lineCount, nil
-}
-
-// ---------------- Core FS helpers ----------------
-
-func isDir(path string) bool {
-	info, err := os.Stat(path)
-	if err != nil {
-		return false
 	}
-	return info.IsDir()
-}
-
-func (d Directory) getPath() string {
-	return filepath.Join(d.ParentPath, d.Name)
+	return r, nil
 }
 
-func (d Directory) readEntries() []os.DirEntry {
-	path := d.getPath()
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		panic(err)
+// parseModifiedSince parses a --modified-since threshold relative to now:
+// a duration before now in time.ParseDuration syntax or with a d (day) or
+// w (week) suffix, e.g. "7d" or "48h", or a point in time as an RFC 3339
+// timestamp or a local date such as "2024-05-01".
+func parseModifiedSince(v string, now time.Time) (time.Time, error) {
+	s := strings.TrimSpace(v)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
 	}
-	return entries
-}
-
-// Robust line counter (handles long lines)
-func countLinesInFile(path string) (int, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return 0, err
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
 	}
-	defer file.Close()
-
-	reader := bufio.NewReader(file)
-	count := 0
-	for {
-		_, err := reader.ReadString('\n')
-		if err == io.EOF {
-			break
+	var age time.Duration
+	var err error
+	switch {
+	case strings.HasSuffix(s, "d"), strings.HasSuffix(s, "w"):
+		unit := 24 * time.Hour
+		if strings.HasSuffix(s, "w") {
+			unit *= 7
 		}
-		if err != nil {
-			return count, err
+		var n float64
+		if n, err = strconv.ParseFloat(s[:len(s)-1], 64); err == nil {
+			age = time.Duration(n * float64(unit))
 		}
-		count++
+	default:
+		age, err = time.ParseDuration(s)
 	}
-	return count, nil
+	if err != nil || age <= 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q (want a duration such as 7d or 48h, or a date such as 2024-05-01)", v)
+	}
+	return now.Add(-age), nil
 }
 
-func countFilesAndLines(paths []string, root string) (int, int) {
-	fileCount := 0
-	lineCount := 0
-
-	for _, path := range paths {
-		if isIgnored(path, root) {
-			continue
-		}
-
-		if isDir(path) {
-			entries, err := os.ReadDir(path)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading dir %s: %v\n", path, err)
-				continue
-			}
-
-			for _, entry := range entries {
-				// Hide dotfiles except .gitignore
-				if strings.HasPrefix(entry.Name(), ".") && entry.Name() != ".gitignore" {
-					continue
-				}
-				childPath := filepath.Join(path, entry.Name())
-				if isIgnored(childPath, root) {
-					continue
-				}
-
-				cf, cl := countFilesAndLines([]string{childPath}, root)
-				fileCount += cf
-				lineCount += cl
-			}
-		} else {
-			if !filters.IsTextFile(path) {
-				continue
-			}
-			lines, err := countLinesInFile(path)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error counting lines in %s: %v\n", path, err)
-				continue
-			}
-			fileCount++
-			lineCount += lines
-		}
+// compileRegex compiles an --include-regex or --exclude-regex pattern.
+func compileRegex(v string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp %q: %v", v, strings.TrimPrefix(err.Error(), "error parsing regexp: "))
 	}
-	return fileCount, lineCount
+	return re, nil
 }
 
-func getNonHiddenEntries(entries []os.DirEntry) []os.DirEntry {
-	var result []os.DirEntry
-	for _, e := range entries {
-		if strings.HasPrefix(e.Name(), ".") && e.Name() != ".gitignore" {
-			continue
+// readPathList reads newline-separated paths, skipping blank lines.
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if p := strings.TrimSpace(scanner.Text()); p != "" {
+			paths = append(paths, p)
 		}
-		result = append(result, e)
 	}
-	return result
+	return paths, scanner.Err()
 }
 
-// ---------------- Printing ----------------
-
-func (d Directory) printStructure(w io.Writer, root string) {
-	path := d.getPath()
-	entries := getNonHiddenEntries(d.readEntries())
+// stderrIsTerminal reports whether stderr is a terminal rather than a file
+// or pipe, which turns --progress on by default.
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-	for _, entry := range entries {
-		childPath := filepath.Join(path, entry.Name())
-		if isIgnored(childPath, root) {
-			continue
+// ---------------- Clipboard ----------------
+
+// clipboardCommand returns the command that copies its stdin to the system
+// clipboard: pbcopy on macOS, clip on Windows, and wl-copy (under Wayland),
+// xclip, or xsel elsewhere, whichever is installed first.
+func clipboardCommand() (*exec.Cmd, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
 		}
-
-		if entry.IsDir() {
-			fmt.Fprint(w, d.Indent, entry.Name(), "/\n")
-			childDir := Directory{
-				ParentPath: path,
-				Name:       entry.Name(),
-				Indent:     d.Indent + "  ",
-			}
-			childDir.printStructure(w, root)
-		} else {
-			fmt.Fprint(w, d.Indent, entry.Name(), "\n")
+		candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c[0]); err == nil {
+			return exec.Command(path, c[1:]...), nil
 		}
 	}
+	return nil, errors.New("no clipboard tool found (install pbcopy, wl-copy, xclip, or xsel)")
 }
 
-func (d Directory) identifyFileType(entry os.DirEntry) string {
-	ext := filepath.Ext(entry.Name())
-	if len(ext) > 0 {
-		return ext[1:]
+// copyToClipboard puts data on the system clipboard.
+func copyToClipboard(data []byte) error {
+	cmd, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v %s", filepath.Base(cmd.Path), err, strings.TrimSpace(string(out)))
 	}
-	return ""
+	return nil
 }
 
-func (d Directory) printFiles(entries []os.DirEntry, rootPath string, w io.Writer, skipFile string, include string, root string) {
-	entries = getNonHiddenEntries(entries)
-
-	for _, entry := range entries {
-		fullPath := filepath.Join(d.getPath(), entry.Name())
-		if isIgnored(fullPath, root) {
-			continue
-		}
-
-		if entry.IsDir() {
-			childDir := Directory{
-				ParentPath: d.getPath(),
-				Name:       entry.Name(),
-				Indent:     d.Indent + "  ",
-			}
-			childDir.printFiles(childDir.readEntries(), rootPath, w, skipFile, include, root)
-			continue
-		}
-
-		if include != "" && filepath.Ext(entry.Name()) != include {
-			continue
-		}
-
-		absFull, _ := filepath.Abs(fullPath)
-		absSkip, _ := filepath.Abs(skipFile)
-		if skipFile != "" && absFull == absSkip {
-			continue
-		}
-
-		data, err := os.ReadFile(fullPath)
-		if err != nil {
-			fmt.Fprintf(w, "Error reading %s: %v\n", fullPath, err)
+// parseExtList splits a comma-separated list of extensions, normalizing each
+// to lowercase with a leading dot. File names such as "app.js" contribute
+// their extension.
+func parseExtList(list string) []string {
+	var exts []string
+	for _, ext := range strings.Split(list, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
 			continue
 		}
-
-		// Only print text-ish files
-		if utf8.Valid(data) && filters.IsTextFile(fullPath) {
-			relPath, err := filepath.Rel(rootPath, fullPath)
-			if err != nil {
-				relPath = fullPath
-			}
-			fileType := d.identifyFileType(entry)
-			fmt.Fprintf(w, "### File: %v\n", relPath)
-			fmt.Fprintf(w, "```%v\n", fileType)
-			fmt.Fprintf(w, "%v\n```\n", string(data))
+		if e := filepath.Ext(ext); e != "" {
+			ext = e
+		} else {
+			ext = "." + ext
 		}
+		exts = append(exts, ext)
 	}
+	return exts
 }
 
-// ---------------- Git info ----------------
-
-func (d Directory) GetLatestCommit() (*GitInfo, error) {
-	cmd := exec.Command("git", "-C", d.ParentPath, "log", "-1", "--pretty=format:%H|%an|%ad")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		return nil, err
+func main() {
+	o, err := parseArgs(os.Args[1:])
+	if err == flag.ErrHelp {
+		printUsage(os.Stdout)
+		return
 	}
-
-	parts := strings.SplitN(out.String(), "|", 3)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("unexpected git log format")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		printUsage(os.Stderr)
+		os.Exit(2)
 	}
-
-	branchCmd := exec.Command("git", "-C", d.ParentPath, "rev-parse", "--abbrev-ref", "HEAD")
-	var branchOut bytes.Buffer
-	branchCmd.Stdout = &branchOut
-	if err := branchCmd.Run(); err != nil {
-		return nil, err
+	if o.JSONSchema {
+		os.Stdout.Write(reporeader.JSONSchema)
+		return
 	}
-
-	return &GitInfo{
-		Hash:   parts[0],
-		Author: parts[1],
-		Date:   parts[2],
-		Branch: strings.TrimSpace(branchOut.String()),
-	}, nil
-}
-
-// ---------------- Main output ----------------
-
-func output(args []string) {
-	length := len(args)
-	var folderPath string
-	var w io.Writer
-	var include string
-	var skipFile string
-	var filePaths []string
-
-	targetPath, err := filepath.Abs(args[1])
-	if err != nil {
-		panic(err)
+	if o.Path == "" {
+		printUsage(os.Stdout)
+		return
 	}
-
-	if isDir(targetPath) {
-		folderPath = targetPath
-		filePaths = nil
-		loadGitignores(folderPath)
-	} else {
-		folderPath = filepath.Dir(targetPath)
-		filePaths = []string{targetPath}
-		loadGitignores(folderPath)
+	if o.Path == "-" {
+		if o.Files, err = readPathList(os.Stdin); err != nil {
+			fmt.Fprintln(os.Stderr, "Error: reading paths from stdin:", err)
+			os.Exit(1)
+		}
+		if len(o.Files) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no paths on stdin")
+			os.Exit(1)
+		}
+		o.Path = ""
 	}
-
-	dir := Directory{
-		ParentPath: folderPath,
-		Name:       "",
-		Indent:     "",
+	if err := o.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if o.Clipboard && (o.Output != "" || o.OutputDir != "" || o.Watch) {
+		fmt.Fprintln(os.Stderr, "Error: --clipboard cannot be used with -o, --output-dir, or --watch")
+		os.Exit(1)
 	}
 
-	if length > 2 && args[length-2] == "o" {
-		ww, err := os.Create(args[length-1])
+	if o.CPUProfile != "" {
+		f, err := os.Create(o.CPUProfile)
 		if err != nil {
-			panic(err)
+			fmt.Fprintf(os.Stderr, "Error creating CPU profile: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting CPU profile: %v\n", err)
+			os.Exit(1)
 		}
-		w = ww
-		absSkip, _ := filepath.Abs(args[length-1])
-		skipFile = absSkip
-	} else {
-		w = os.Stdout
-		skipFile = ""
+		defer pprof.StopCPUProfile()
 	}
 
-	if len(args) > 2 && args[2] == "--include" {
-		include = filepath.Ext(args[3])
-	} else {
-		include = ""
+	var out io.Writer = os.Stdout
+	var buf bytes.Buffer
+	if o.Clipboard {
+		out = &buf
 	}
-
-	fmt.Fprintf(w, "# Repository Context\n\n")
-	fmt.Fprintf(w, "## File System Location\n\n")
-	fmt.Fprintln(w, folderPath)
-	fmt.Fprintf(w, "## Git Info\n\n")
-
-	gitInfo, err := dir.GetLatestCommit()
-	if err == nil {
-		fmt.Fprintf(w, "- Commit: %v\n", gitInfo.Hash)
-		fmt.Fprintf(w, "- Branch: %v\n", gitInfo.Branch)
-		fmt.Fprintf(w, "- Author: %v\n", gitInfo.Author)
-		fmt.Fprintf(w, "- Date: %v\n", gitInfo.Date)
+	if err := reporeader.Generate(o.Options, out); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		pprof.StopCPUProfile()
+		os.Exit(1)
 	}
-
-	fmt.Fprintf(w, "## Structure\n\n")
-	fmt.Fprintln(w, "```")
-	dir.printStructure(w, folderPath)
-	fmt.Fprintln(w, "```")
-
-	fmt.Fprintf(w, "## File Contents\n\n")
-	if len(filePaths) == 0 {
-		dir.printFiles(dir.readEntries(), folderPath, w, skipFile, include, folderPath)
-	} else {
-		for _, filePath := range filePaths {
-			if isIgnored(filePath, folderPath) {
-				continue
-			}
-			data, err := os.ReadFile(filePath)
-			if err != nil {
-				fmt.Fprintf(w, "Error reading %s: %v\n", filePath, err)
-				continue
-			}
-			if utf8.Valid(data) && filters.IsTextFile(filePath) {
-				fileType := strings.TrimPrefix(filepath.Ext(filePath), ".")
-				fmt.Fprintf(w, "### File: %v\n", filepath.Base(filePath))
-				fmt.Fprintf(w, "```%v\n", fileType)
-				fmt.Fprintf(w, "%v\n```\n", string(data))
-			}
+	if o.Clipboard {
+		if err := copyToClipboard(buf.Bytes()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; writing to stdout instead\n", err)
+			os.Stdout.Write(buf.Bytes())
 		}
 	}
 
-	// Summary (prefer Git-tracked; fallback to FS walk)
-	var fileCount, lineCount int
-	if len(filePaths) == 0 {
-		if isGitRepo(folderPath) {
-			if fc, lc, err := countFilesAndLinesGit(folderPath); err == nil {
-				fileCount, lineCount = fc, lc
-			} else {
-				entries := getNonHiddenEntries(dir.readEntries())
-				var childPaths []string
-				for _, entry := range entries {
-					childPath := filepath.Join(folderPath, entry.Name())
-					if isIgnored(childPath, folderPath) {
-						continue
-					}
-					childPaths = append(childPaths, childPath)
-				}
-				fileCount, lineCount = countFilesAndLines(childPaths, folderPath)
-			}
-		} else {
-			entries := getNonHiddenEntries(dir.readEntries())
-			var childPaths []string
-			for _, entry := range entries {
-				childPath := filepath.Join(folderPath, entry.Name())
-				if isIgnored(childPath, folderPath) {
-					continue
-				}
-				childPaths = append(childPaths, childPath)
-			}
-			fileCount, lineCount = countFilesAndLines(childPaths, folderPath)
+	if o.MemProfile != "" {
+		f, err := os.Create(o.MemProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating memory profile: %v\n", err)
+			return
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing memory profile: %v\n", err)
 		}
-	} else {
-		fileCount, lineCount = countFilesAndLines(filePaths, folderPath)
-	}
-
-	fmt.Fprintf(w, "## Summary\n- Total files: %v\n- Total lines: %v\n", fileCount, lineCount)
-}
-
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: myreporeader <path> [--include .ext] [o outputfile]")
-		return
 	}
-	output(os.Args)
 }
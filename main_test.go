@@ -0,0 +1,245 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whoisrgxu/myreporeader/reporeader"
+)
+
+func TestMain(m *testing.M) {
+	// runMain re-executes the test binary with this set to run the command.
+	if os.Getenv("MYREPOREADER_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command with args in a child process and returns its
+// standard output.
+func runMain(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "MYREPOREADER_RUN_MAIN=1")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("myreporeader %v: %v", args, err)
+	}
+	return string(out)
+}
+
+func TestProfiles(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "src")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cpu, mem := filepath.Join(dir, "cpu.prof"), filepath.Join(dir, "mem.prof")
+	runMain(t, "--profile", cpu, "--memprofile", mem, root)
+
+	for _, path := range []string{cpu, mem} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s is empty", filepath.Base(path))
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args      []string
+		path, out string
+		hidden    bool
+		maxLines  int
+		wantErr   string
+	}{
+		{args: []string{"."}, path: "."},
+		{args: []string{"-o", "out.md", "src"}, path: "src", out: "out.md"},
+		// Flags may follow the path.
+		{args: []string{"src", "--hidden", "--max-lines", "40"}, path: "src", hidden: true, maxLines: 40},
+		// The legacy trailing "o outputfile" form.
+		{args: []string{"src", "o", "out.md"}, path: "src", out: "out.md"},
+		{args: []string{"src", "o", "a.md", "-o", "b.md"}, wantErr: "output file given twice (-o and o)"},
+		{args: []string{"a", "b"}, wantErr: "unexpected arguments: b"},
+		{args: []string{"--max-lines", "x", "."}, wantErr: `invalid value "x" for flag -max-lines: parse error`},
+		{args: []string{"--no-such-flag", "."}, wantErr: "flag provided but not defined: -no-such-flag"},
+		{args: []string{"--include-regex", "(", "."}, wantErr: "invalid value \"(\" for flag -include-regex: invalid regexp \"(\": missing closing ): `(`"},
+		{args: nil},
+	} {
+		o, err := parseArgs(tc.args)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("parseArgs(%q) error = %v, want %q", tc.args, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseArgs(%q): %v", tc.args, err)
+			continue
+		}
+		if o.Path != tc.path || o.Output != tc.out || o.Hidden != tc.hidden || o.MaxLines != tc.maxLines {
+			t.Errorf("parseArgs(%q) = path %q, -o %q, hidden %v, max lines %d", tc.args, o.Path, o.Output, o.Hidden, o.MaxLines)
+		}
+	}
+}
+
+func TestMaxBinarySizeFlag(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want int64
+	}{
+		{[]string{"."}, 64 << 10},
+		{[]string{"--max-binary-size", "1MB", "."}, 1 << 20},
+		{[]string{"--max-binary-size", "0", "."}, 0},
+	} {
+		o, err := parseArgs(tc.args)
+		if err != nil {
+			t.Fatalf("parseArgs(%q): %v", tc.args, err)
+		}
+		if o.MaxBinarySize != tc.want {
+			t.Errorf("parseArgs(%q) MaxBinarySize = %d, want %d", tc.args, o.MaxBinarySize, tc.want)
+		}
+	}
+	if got := newFlagSet(&cliOptions{}).Lookup("max-binary-size").DefValue; got != "64KB" {
+		t.Errorf("--max-binary-size default = %q, want 64KB", got)
+	}
+}
+
+func TestParseSizeRange(t *testing.T) {
+	for _, tc := range []struct {
+		v       string
+		want    reporeader.SizeRange
+		wantErr string
+	}{
+		{v: "0-100", want: reporeader.SizeRange{Min: 0, Max: 100}},
+		{v: "1MB-", want: reporeader.SizeRange{Min: 1 << 20, Max: -1}},
+		{v: "-2KB", want: reporeader.SizeRange{Min: 0, Max: 2048}},
+		{v: "1.5KB-1MB", want: reporeader.SizeRange{Min: 1536, Max: 1 << 20}},
+		{v: "-", wantErr: `invalid size range "-" (want MIN-MAX, MIN-, or -MAX)`},
+		{v: "100", wantErr: `invalid size range "100" (want MIN-MAX, MIN-, or -MAX)`},
+		{v: "2KB-1KB", wantErr: `invalid size range "2KB-1KB" (MAX is below MIN)`},
+		{v: "x-1", wantErr: `invalid size "x"`},
+	} {
+		got, err := parseSizeRange(tc.v)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("parseSizeRange(%q) error = %v, want %q", tc.v, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseSizeRange(%q) = %+v, %v, want %+v", tc.v, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseModifiedSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		v       string
+		want    time.Time
+		wantErr bool
+	}{
+		{v: "7d", want: now.AddDate(0, 0, -7)},
+		{v: "48h", want: now.Add(-48 * time.Hour)},
+		{v: "2w", want: now.AddDate(0, 0, -14)},
+		{v: "1.5d", want: now.Add(-36 * time.Hour)},
+		{v: "2024-05-01T08:00:00Z", want: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{v: "2024-05-01", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{v: "0d", wantErr: true},
+		{v: "-3h", wantErr: true},
+		{v: "soon", wantErr: true},
+	} {
+		got, err := parseModifiedSince(tc.v, now)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseModifiedSince(%q) = %v, want an error", tc.v, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("parseModifiedSince(%q) = %v, %v, want %v", tc.v, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseInclude(t *testing.T) {
+	o, err := parseArgs([]string{"--include", ".go, PROTO", "--include", "ts", "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct{}{".go": {}, ".proto": {}, ".ts": {}}
+	if !maps.Equal(o.Include, want) {
+		t.Errorf("Include = %v, want %v", o.Include, want)
+	}
+}
+
+func TestParseExtList(t *testing.T) {
+	for v, want := range map[string][]string{
+		".feature":         {".feature"},
+		"bicep, .PRISMA,,": {".bicep", ".prisma"},
+		"schema.graphql":   {".graphql"},
+		"":                 nil,
+	} {
+		if got := parseExtList(v); !slices.Equal(got, want) {
+			t.Errorf("parseExtList(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestPathsFromStdin(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".gitignore": "*.log\n",
+		"a/x.go":     "package a\n",
+		"b/y.go":     "package b\n",
+		"b/z.go":     "package b\n",
+		"c.log":      "noise\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Relative paths resolve against the working directory.
+	cmd := exec.Command(os.Args[0], "-")
+	cmd.Env = append(os.Environ(), "MYREPOREADER_RUN_MAIN=1")
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader("a/x.go\n\n  " + filepath.Join(root, "b", "y.go") + "\nmissing.go\nc.log\n")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("myreporeader -: %v\n%s", err, stderr.String())
+	}
+	for _, want := range []string{"### File: a/x.go\n", "### File: b/y.go\n", "- Total files: 2\n"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "### File: b/z.go") {
+		t.Errorf("output includes an unlisted file:\n%s", out)
+	}
+	for _, want := range []string{"Skipping " + filepath.Join(root, "missing.go"), "Skipping " + filepath.Join(root, "c.log") + ": ignored by"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr lacks %q:\n%s", want, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package reporeader_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/whoisrgxu/myreporeader/reporeader"
+)
+
+// TestGenerateAPI uses the package only through its exported API, the way
+// an embedding program would.
+func TestGenerateAPI(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := reporeader.Generate(reporeader.Options{Path: root, Format: "json"}, &b); err != nil {
+		t.Fatal(err)
+	}
+	var r reporeader.Report
+	if err := json.Unmarshal([]byte(b.String()), &r); err != nil {
+		t.Fatalf("output is not a Report: %v", err)
+	}
+	if len(r.Files) != 1 || r.Files[0].Path != "main.go" || r.Files[0].Lines != 3 {
+		t.Errorf("Files = %+v, want main.go with 3 lines", r.Files)
+	}
+	if r.Summary.Files != 1 {
+		t.Errorf("Summary.Files = %d, want 1", r.Summary.Files)
+	}
+
+	err := reporeader.Generate(reporeader.Options{}, &b)
+	if err == nil || err.Error() != "missing <path> argument" {
+		t.Errorf("Generate with no target = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,153 @@
+package reporeader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isArchive reports whether path names a supported archive.
+func isArchive(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractArchive unpacks the archive at path into a new temporary directory
+// and returns it, so the archive can be read like any directory. Symlinks,
+// special files, .git directories, and entries escaping the root are skipped.
+func extractArchive(path string) (string, error) {
+	dest, err := os.MkdirTemp("", "myreporeader-")
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		err = extractZip(path, dest)
+	} else {
+		err = extractTar(path, dest)
+	}
+	if err != nil {
+		os.RemoveAll(dest)
+		return "", err
+	}
+	return dest, nil
+}
+
+// archiveEntryPath maps an archive entry name to a path under dest, or ""
+// if the entry must be skipped.
+func archiveEntryPath(dest string, name string) string {
+	name = filepath.FromSlash(strings.TrimPrefix(name, "/"))
+	target := filepath.Join(dest, name)
+	if !isWithin(target, dest) || target == dest {
+		return ""
+	}
+	for _, seg := range strings.Split(filepath.ToSlash(name), "/") {
+		if seg == ".git" {
+			return ""
+		}
+	}
+	return target
+}
+
+func writeArchiveFile(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func extractZip(path string, dest string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		target := archiveEntryPath(dest, zf.Name)
+		if target == "" {
+			continue
+		}
+		mode := zf.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArchiveFile(target, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTar(path string, dest string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		target := archiveEntryPath(dest, hdr.Name)
+		if target == "" {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
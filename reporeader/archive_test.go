@@ -0,0 +1,60 @@
+package reporeader
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeZip creates an archive of files, keyed by entry name, and returns
+// its path.
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "src.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestZipArchive(t *testing.T) {
+	path := writeZip(t, map[string]string{
+		"main.go":           "package main\n",
+		"docs/readme.md":    "# readme\n",
+		"../escape.txt":     "outside\n",
+		".git/config":       "[core]\n",
+		"node_modules/x.js": "x\n",
+	})
+	out := generate(t, Options{Path: path})
+	if got, want := fileHeaders(out), []string{"docs/readme.md", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("prints %q, want %q", got, want)
+	}
+	for _, want := range []string{"# readme\n", "package main\n", "- Total files: 2\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "outside") {
+		t.Errorf("entry outside the root extracted:\n%s", out)
+	}
+}
@@ -0,0 +1,93 @@
+//go:build unix
+
+package reporeader
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestDeniedFileNeverOpened replaces denied files with FIFOs. Opening a
+// FIFO for reading blocks until a writer shows up, so any attempt to sniff,
+// count, or print one hangs the run instead of passing unnoticed.
+func TestDeniedFileNeverOpened(t *testing.T) {
+	for _, useGit := range []bool{false, true} {
+		name := "filesystem"
+		if useGit {
+			name = "git"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := writeTree(t, map[string]string{
+				"main.go":       "package main\n",
+				"keys/id_rsa":   "secret\n",
+				".env":          "TOKEN=secret\n",
+				".gitignore":    "",
+				"keys/notes.md": "# notes\n",
+			})
+			if useGit {
+				gitInit(t, root)
+			}
+			var fifos []string
+			for _, name := range []string{"keys/id_rsa", ".env"} {
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+				if err := syscall.Mkfifo(path, 0o644); err != nil {
+					t.Skipf("mkfifo: %v", err)
+				}
+				fifos = append(fifos, path)
+			}
+
+			opts := Options{
+				Path:           root,
+				Deny:           []string{".env"},
+				Hidden:         true,
+				DirStats:       true,
+				LargeFileLines: 1,
+				MaxConcurrency: 4,
+			}
+			type result struct {
+				out string
+				err error
+			}
+			done := make(chan result, 1)
+			go func() {
+				var b strings.Builder
+				err := Generate(opts, &b)
+				done <- result{b.String(), err}
+			}()
+
+			select {
+			case res := <-done:
+				if res.err != nil {
+					t.Fatalf("Generate: %v", res.err)
+				}
+				got := fileHeaders(res.out)
+				want := []string{".gitignore", "keys/notes.md", "main.go"}
+				if !slices.Equal(got, want) {
+					t.Errorf("printed files = %q, want %q", got, want)
+				}
+				if !strings.Contains(res.out, "- Total files: 3\n") {
+					t.Errorf("Summary counts denied files:\n%s", res.out)
+				}
+				if !strings.Contains(res.out, "id_rsa") {
+					t.Errorf("denied file missing from Structure:\n%s", res.out)
+				}
+			case <-time.After(10 * time.Second):
+				// Unblock the reader so the goroutine can finish.
+				for _, fifo := range fifos {
+					if f, err := os.OpenFile(fifo, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+						f.Close()
+					}
+				}
+				t.Fatal("the run opened a denied file")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package reporeader
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the user's git configuration and global excludes file out of
+	// the tests.
+	home, err := os.MkdirTemp("", "reporeader-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	os.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// writeTree creates files, keyed by slash-separated path, under a new
+// temporary directory and returns the directory.
+func writeTree(t testing.TB, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// syntheticTree writes n small text files with extension ext spread over
+// nested directories, for benchmarks.
+func syntheticTree(tb testing.TB, n int, ext string) string {
+	tb.Helper()
+	files := make(map[string]string, n)
+	for i := range n {
+		name := fmt.Sprintf("pkg%d/sub%d/file%d%s", i%20, i%7, i, ext)
+		files[name] = strings.Repeat(fmt.Sprintf("// line of file %d\n", i), 40)
+	}
+	return writeTree(tb, files)
+}
+
+// generate runs Generate with opts and returns what it wrote to w.
+func generate(t *testing.T, opts Options) string {
+	t.Helper()
+	var b strings.Builder
+	if err := Generate(opts, &b); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return b.String()
+}
+
+// captureStderr runs fn and returns what it wrote to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved := os.Stderr
+	os.Stderr = f
+	defer func() { os.Stderr = saved }()
+	fn()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// fileHeaders returns the paths of the "### File:" headings in a Markdown
+// document, in order.
+func fileHeaders(doc string) []string {
+	var paths []string
+	for _, line := range strings.Split(doc, "\n") {
+		if path, ok := strings.CutPrefix(line, "### File: "); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// gitInit makes dir a git repository with everything in it committed.
+func gitInit(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "-A"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		git(t, dir, args...)
+	}
+}
+
+// git runs a git command in dir, failing the test on error.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
@@ -0,0 +1,129 @@
+//go:build unix
+
+package reporeader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stubGit replaces git on PATH with a shell script for the rest of the
+// test. cases are the branches of a case statement on the git command,
+// which follows "-C <dir>"; any other command fails.
+func stubGit(t *testing.T, cases string) {
+	t.Helper()
+	bin := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\ncase \"$3\" in\n%s\n*) exit 1 ;;\nesac\n", cases)
+	if err := os.WriteFile(filepath.Join(bin, "git"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+}
+
+func TestGetLatestCommitAuthor(t *testing.T) {
+	stubGit(t, `log) printf 'abc123\037Ann | Bob, Jr.\0372024-01-02 10:00:00 +0000' ;;
+rev-parse) echo main ;;`)
+
+	info, err := Directory{ParentPath: t.TempDir()}.GetLatestCommit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GitInfo{Hash: "abc123", Author: "Ann | Bob, Jr.", Date: "2024-01-02 10:00:00 +0000", Branch: "main"}
+	if *info != want {
+		t.Errorf("GetLatestCommit = %+v, want %+v", *info, want)
+	}
+}
+
+func TestGitDateRelative(t *testing.T) {
+	stubGit(t, `log)
+	case "$*" in
+	*--date=relative*) printf 'abc123\037Ann\0373 days ago' ;;
+	*) printf 'abc123\037Ann\037Mon Jan 1 10:00:00 2024 +0000' ;;
+	esac ;;
+rev-parse) echo main ;;`)
+	root := writeTree(t, map[string]string{"main.go": "package main\n"})
+
+	out := generate(t, Options{Path: root, GitDateRelative: true})
+	if want := "- Date: 3 days ago\n"; !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+	out = generate(t, Options{Path: root})
+	if want := "- Date: Mon Jan 1 10:00:00 2024 +0000\n"; !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+}
+
+func TestGitStatusAnnotations(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"clean.go":  "package a\n",
+		"mod.go":    "package a\n",
+		"staged.go": "package a\n",
+		"new.go":    "package a\n",
+	})
+	stubGit(t, fmt.Sprintf(`log) printf 'abc123\037Ann\0372024-01-02' ;;
+rev-parse)
+	case "$4" in
+	--show-toplevel) echo %q ;;
+	*) echo main ;;
+	esac ;;
+ls-files) printf 'clean.go\0mod.go\0staged.go\0' ;;
+status) printf ' M mod.go\0MM staged.go\0?? new.go\0' ;;`, root))
+
+	out := generate(t, Options{Path: root, GitStatus: true})
+	want := []string{"clean.go", "mod.go (modified)", "new.go (untracked)", "staged.go (staged, modified)"}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+
+	out = generate(t, Options{Path: root})
+	if got := fileHeaders(out); !slices.Equal(got, []string{"clean.go", "mod.go", "new.go", "staged.go"}) {
+		t.Errorf("annotations without --git-status: %q", got)
+	}
+}
+
+func TestRemoteURL(t *testing.T) {
+	root := writeTree(t, map[string]string{"main.go": "package main\n"})
+	commit := `log) printf 'abc123\037Ann\0372024-01-02' ;;
+rev-parse) echo main ;;`
+
+	stubGit(t, commit+"\nremote) echo git@github.com:user/repo.git ;;")
+	if out := generate(t, Options{Path: root}); !strings.Contains(out, "- Branch: main\n- Remote: https://github.com/user/repo\n- Author: Ann\n") {
+		t.Errorf("no Remote line:\n%s", out)
+	}
+
+	// Without an origin, git remote get-url fails and the line is left out.
+	stubGit(t, commit)
+	if out := generate(t, Options{Path: root}); strings.Contains(out, "- Remote:") {
+		t.Errorf("Remote line without an origin:\n%s", out)
+	}
+}
+
+func TestGitLogFailureFallsBack(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"a.go":      "package a\n",
+		"b.go":      "package a\n",
+		"c.go":      "package a\n",
+	})
+
+	// Without git at all, the filesystem is walked.
+	stubGit(t, "")
+	want := generate(t, Options{Path: root})
+
+	// A repository whose log fails (e.g. no commits yet) gets the same
+	// filesystem view, not a Summary from ls-files alone.
+	stubGit(t, `log) echo "fatal: your current branch 'main' does not have any commits yet" >&2; exit 128 ;;
+ls-files) printf 'a.go\0' ;;`)
+	out := generate(t, Options{Path: root})
+	if out != want {
+		t.Errorf("output with a failing git log =\n%s\nwant\n%s", out, want)
+	}
+	if !strings.Contains(out, "## Git Info\n\n## Structure") || !strings.Contains(out, "- Total files: 3\n") {
+		t.Errorf("unexpected fallback output:\n%s", out)
+	}
+}
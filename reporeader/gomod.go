@@ -0,0 +1,185 @@
+package reporeader
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readModulePath returns the module path declared in dir/go.mod, or "".
+func readModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+		}
+	}
+	return ""
+}
+
+// countGoOrigin classifies the .go files under root as first-party (part of
+// the root module) or third-party (under vendor/ or in a nested module with
+// an unrelated path). Vendored code is counted even though vendor/ is
+// ignored by default. ok is false when root has no go.mod.
+func (g *generator) countGoOrigin(root string) (module string, firstParty int, thirdParty int, ok bool) {
+	module = readModulePath(root)
+	if module == "" {
+		return "", 0, 0, false
+	}
+
+	var walk func(dir string, third bool)
+	walk = func(dir string, third bool) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range g.getNonHiddenEntries(entries) {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				childThird := third || entry.Name() == "vendor"
+				if nested := readModulePath(path); nested != "" && nested != module && !strings.HasPrefix(nested, module+"/") {
+					childThird = true
+				}
+				if !childThird && g.isIgnored(path, root) {
+					continue
+				}
+				walk(path, childThird)
+				continue
+			}
+			if filepath.Ext(entry.Name()) != ".go" {
+				continue
+			}
+			if third {
+				thirdParty++
+			} else if !g.isIgnored(path, root) {
+				firstParty++
+			}
+		}
+	}
+	walk(root, false)
+	return module, firstParty, thirdParty, true
+}
+
+// findModuleRoot returns the nearest directory at or above dir with a
+// go.mod, and the module path it declares, or "" when there is none.
+func findModuleRoot(dir string) (root string, module string) {
+	for {
+		if module = readModulePath(dir); module != "" {
+			return dir, module
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// goDepOrder reorders r.Files for --go-dep-order so that Go packages come
+// leaves first: a package follows every package of the same module it
+// imports, and main packages come last. Imports are read from the printed
+// sources with go/parser, so only included files count, and packages of
+// other modules are not considered. Non-Go files keep their place ahead of
+// the Go files, and the files of one package stay together in tree order.
+// Nothing changes outside a module.
+func goDepOrder(r *Report, location string) {
+	modRoot, module := findModuleRoot(location)
+	if module == "" {
+		return
+	}
+
+	// Import path of each printed package, its imports within the module,
+	// and whether it is a main package
+	pkgOf := map[int]string{}
+	deps := map[string]map[string]bool{}
+	isMain := map[string]bool{}
+	for i, f := range r.Files {
+		if filepath.Ext(f.Path) != ".go" || f.Content == "" {
+			continue
+		}
+		rel, err := filepath.Rel(modRoot, filepath.Dir(f.absPath))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		pkg := module
+		if rel != "." {
+			pkg += "/" + filepath.ToSlash(rel)
+		}
+		pkgOf[i] = pkg
+		if deps[pkg] == nil {
+			deps[pkg] = map[string]bool{}
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), f.Path, f.Content, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		if file.Name.Name == "main" {
+			isMain[pkg] = true
+		}
+		for _, imp := range file.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err == nil && path != pkg && (path == module || strings.HasPrefix(path, module+"/")) {
+				deps[pkg][path] = true
+			}
+		}
+	}
+
+	// level is 0 for packages importing no printed package of the module,
+	// else one more than their highest import. visiting guards against
+	// import cycles, which the go tool would reject anyway.
+	levels := map[string]int{}
+	visiting := map[string]bool{}
+	var level func(pkg string) int
+	level = func(pkg string) int {
+		if l, ok := levels[pkg]; ok {
+			return l
+		}
+		if visiting[pkg] {
+			return 0
+		}
+		visiting[pkg] = true
+		l := 0
+		for dep := range deps[pkg] {
+			if _, printed := deps[dep]; printed {
+				l = max(l, level(dep)+1)
+			}
+		}
+		levels[pkg] = l
+		return l
+	}
+
+	less := func(a, b string) bool {
+		if isMain[a] != isMain[b] {
+			return isMain[b]
+		}
+		if la, lb := level(a), level(b); la != lb {
+			return la < lb
+		}
+		return a < b
+	}
+	order := make([]int, len(r.Files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		a, aGo := pkgOf[order[x]]
+		b, bGo := pkgOf[order[y]]
+		if aGo != bGo {
+			return bGo
+		}
+		return aGo && a != b && less(a, b)
+	})
+	files := make([]File, len(r.Files))
+	for i, j := range order {
+		files[i] = r.Files[j]
+	}
+	r.Files = files
+}
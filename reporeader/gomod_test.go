@@ -0,0 +1,47 @@
+package reporeader
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGoOrigin(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod":                           "module example.com/app\n\ngo 1.22\n",
+		"main.go":                          "package main\n",
+		"internal/x/x.go":                  "package x\n",
+		"sub/go.mod":                       "module example.com/app/sub\n",
+		"sub/s.go":                         "package sub\n",
+		"vendor/github.com/lib/pq/conn.go": "package pq\n",
+		"vendor/modules.txt":               "# github.com/lib/pq v1.0.0\n",
+		"tools/go.mod":                     "module example.com/tools\n",
+		"tools/gen.go":                     "package tools\n",
+	})
+	out := generate(t, Options{Path: root, GoOrigin: true})
+	want := "- Go module: example.com/app\n- First-party: 3 files, Third-party: 2 files\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+
+	if out := generate(t, Options{Path: root}); strings.Contains(out, "First-party") {
+		t.Errorf("Go origin reported without --go-origin:\n%s", out)
+	}
+}
+
+func TestGoDepOrder(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod":       "module example.com/app\n\ngo 1.22\n",
+		"README.md":    "# app\n",
+		"main.go":      "package main\n\nimport (\n\t\"example.com/app/api\"\n\t\"fmt\"\n)\n",
+		"api/api.go":   "package api\n\nimport \"example.com/app/store\"\n",
+		"api/route.go": "package api\n\nimport \"net/http\"\n",
+		"store/db.go":  "package store\n\nimport \"example.com/app/zutil\"\n",
+		"zutil/z.go":   "package zutil\n\nimport \"github.com/other/mod\"\n",
+	})
+	out := generate(t, Options{Path: root, GoDepOrder: true})
+	want := []string{"README.md", "go.mod", "zutil/z.go", "store/db.go", "api/api.go", "api/route.go", "main.go"}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("--go-dep-order prints %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package reporeader
+
+import (
+	"strings"
+
+	"github.com/whoisrgxu/myreporeader/internal/filters"
+	"github.com/whoisrgxu/myreporeader/internal/tokens"
+)
+
+// sharedHeaderNote marks a file whose leading block was moved to the
+// report's SharedHeader by --dedup-headers.
+const sharedHeaderNote = "shared header omitted"
+
+// leadingHeader returns the block of comment and blank lines that opens
+// content, up to and including the last comment line, or "" when the file
+// does not open with at least two comment lines. Only languages known to
+// filters.CommentSyntaxFor have headers.
+func leadingHeader(path string, content string) string {
+	syntax, ok := filters.CommentSyntaxFor(path)
+	if !ok {
+		return ""
+	}
+	end, comments, inBlock := 0, 0, false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.TrimSpace(line) == "" && !inBlock {
+			if comments == 0 {
+				return ""
+			}
+			end += len(line)
+			continue
+		}
+		var comment bool
+		comment, inBlock = syntax.IsComment(line, inBlock)
+		if !comment {
+			break
+		}
+		end += len(line)
+		comments++
+	}
+	if comments < 2 {
+		return ""
+	}
+	return strings.TrimRight(content[:end], " \t\r\n") + "\n"
+}
+
+// dedupHeaders finds the leading comment block shared by the most printed
+// files (at least two), such as a license header, moves it to
+// r.SharedHeader, and strips it from those files with a note. Ties go to
+// the longer header so the most text is saved.
+func dedupHeaders(r *Report) {
+	counts := map[string]int{}
+	headers := make([]string, len(r.Files))
+	for i, f := range r.Files {
+		if f.Error != "" || f.Skipped != "" || f.Hexdump {
+			continue
+		}
+		headers[i] = leadingHeader(f.Path, f.Content)
+		if headers[i] != "" {
+			counts[headers[i]]++
+		}
+	}
+	shared := ""
+	for h, n := range counts {
+		best := counts[shared]
+		if n > best || n == best && (len(h) > len(shared) || len(h) == len(shared) && h < shared) {
+			shared = h
+		}
+	}
+	if counts[shared] < 2 {
+		return
+	}
+	r.SharedHeader = shared
+	for i := range r.Files {
+		if headers[i] != shared {
+			continue
+		}
+		f := &r.Files[i]
+		rest := f.Content[len(strings.TrimRight(shared, "\n")):]
+		// Drop the rest of the header's last line and the blank lines
+		// that separated it from the code.
+		for rest != "" {
+			line, after, _ := strings.Cut(rest, "\n")
+			if strings.TrimSpace(line) != "" {
+				break
+			}
+			rest = after
+		}
+		f.Content = rest
+		f.Tokens = tokens.Estimate(f.Content)
+		f.Notes = append(f.Notes, sharedHeaderNote)
+	}
+}
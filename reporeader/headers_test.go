@@ -0,0 +1,52 @@
+package reporeader
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDedupHeaders(t *testing.T) {
+	const license = "// Copyright 2024 Example.\n// Licensed under MIT.\n"
+	r := &Report{Files: []File{
+		{Path: "a.go", Content: license + "\npackage a\n"},
+		{Path: "b.go", Content: license + "\npackage b\n"},
+		{Path: "c.go", Content: license + "\npackage c\n"},
+		{Path: "other.go", Content: "// Package other does things.\n// It has no license.\npackage other\n"},
+		{Path: "plain.go", Content: "package plain\n"},
+	}}
+	dedupHeaders(r)
+	if r.SharedHeader != license {
+		t.Errorf("SharedHeader = %q, want %q", r.SharedHeader, license)
+	}
+	var stripped []string
+	for _, f := range r.Files {
+		if slices.Contains(f.Notes, sharedHeaderNote) {
+			stripped = append(stripped, f.Path)
+			if want := "package " + strings.TrimSuffix(f.Path, ".go") + "\n"; f.Content != want {
+				t.Errorf("%v content = %q, want %q", f.Path, f.Content, want)
+			}
+		}
+	}
+	if want := []string{"a.go", "b.go", "c.go"}; !slices.Equal(stripped, want) {
+		t.Errorf("stripped %q, want %q", stripped, want)
+	}
+
+	root := writeTree(t, map[string]string{
+		"a.go": license + "\npackage a\n",
+		"b.go": license + "\npackage b\n",
+		"c.go": license + "\npackage c\n",
+	})
+	out := generate(t, Options{Path: root, DedupHeaders: true})
+	if n := strings.Count(out, "Licensed under MIT."); n != 1 {
+		t.Errorf("license printed %d times, want 1:\n%s", n, out)
+	}
+	if !strings.Contains(out, "### File: a.go ("+sharedHeaderNote+")") {
+		t.Errorf("a.go header lacks the note:\n%s", out)
+	}
+
+	out = generate(t, Options{Path: root})
+	if n := strings.Count(out, "Licensed under MIT."); n != 3 {
+		t.Errorf("without --dedup-headers license printed %d times, want 3", n)
+	}
+}
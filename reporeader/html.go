@@ -0,0 +1,183 @@
+package reporeader
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlStyle is the page's only styling; it keeps the page readable without
+// getting in the way of a highlighter's theme.
+const htmlStyle = `body{font-family:system-ui,sans-serif;max-width:70em;margin:2em auto;padding:0 1em;line-height:1.4}
+pre{background:#f6f8fa;padding:.8em;overflow:auto}
+summary{cursor:pointer;font-weight:bold;margin:.5em 0}
+table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:.2em .6em}
+.error{color:#b00}`
+
+// htmlRenderer writes the report as one self-contained HTML page. Files are
+// collapsible <details> blocks whose <code> elements carry a
+// language-<name> class, so a client-side highlighter such as highlight.js
+// can be dropped in. It shares path shortening and line numbering with the
+// Markdown renderer; --combine-small and --split-large-files do not apply.
+type htmlRenderer struct {
+	markdownRenderer
+}
+
+func (h htmlRenderer) Render(w io.Writer, r *Report) error {
+	esc := html.EscapeString
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(w, "<title>Repository Context: %v</title>\n<style>\n%v\n</style>\n</head>\n<body>\n", esc(r.Root), htmlStyle)
+	fmt.Fprintf(w, "<h1>Repository Context</h1>\n")
+	fmt.Fprintf(w, "<h2>File System Location</h2>\n<p><code>%v</code></p>\n", esc(r.Root))
+	fmt.Fprintf(w, "<h2>Git Info</h2>\n")
+	if g := r.Git; g != nil {
+		fmt.Fprintf(w, "<ul>\n<li>Commit: %v</li>\n<li>Branch: %v</li>\n", esc(g.Hash), esc(g.Branch))
+		if g.Remote != "" {
+			fmt.Fprintf(w, "<li>Remote: <a href=\"%v\">%v</a></li>\n", esc(g.Remote), esc(g.Remote))
+		}
+		fmt.Fprintf(w, "<li>Author: %v</li>\n<li>Date: %v</li>\n</ul>\n", esc(g.Author), esc(g.Date))
+	}
+	if h.opts.SummaryPosition == "top" && !h.opts.StructureOnly {
+		h.printSummary(w, r.Summary)
+	}
+
+	if !h.opts.NoStructure {
+		fmt.Fprintf(w, "<h2>Structure</h2>\n")
+		var tree strings.Builder
+		if h.opts.StructureFormat == "mermaid" {
+			fmt.Fprintln(&tree, "graph TD")
+			fmt.Fprintf(&tree, "  n0[\"%v\"]\n", mermaidLabel(r.Structure.Name+"/"))
+			nextID := 1
+			printMermaid(&tree, r.Structure, "n0", &nextID)
+			fmt.Fprintf(w, "<pre class=\"mermaid\">\n%v</pre>\n", esc(tree.String()))
+		} else {
+			connectors := unicodeTree
+			if h.opts.ASCII {
+				connectors = asciiTree
+			}
+			if h.opts.Indent != 0 {
+				connectors = connectors.indented(h.opts.Indent)
+			}
+			printStructure(&tree, r.Structure, "", connectors)
+			fmt.Fprintf(w, "<pre>\n%v</pre>\n", esc(tree.String()))
+		}
+	}
+	if h.opts.StructureOnly {
+		_, err := fmt.Fprintf(w, "</body>\n</html>\n")
+		return err
+	}
+
+	if r.SharedHeader != "" {
+		fmt.Fprintf(w, "<h2>Shared Header</h2>\n")
+		fmt.Fprintf(w, "<p>Omitted from the start of each file marked &quot;%v&quot;:</p>\n", sharedHeaderNote)
+		fmt.Fprintf(w, "<pre><code>%v</code></pre>\n", esc(r.SharedHeader))
+	}
+
+	// ids maps each file's (displayed) path to the id of its block, for
+	// --toc and --link-references.
+	ids := map[string]string{}
+	for i, f := range r.Files {
+		ids[h.shortPath(f.Path)] = fmt.Sprintf("file-%d", i+1)
+	}
+	if h.opts.TOC {
+		fmt.Fprintf(w, "<h2>Table of Contents</h2>\n<ul>\n")
+		for _, f := range r.Files {
+			if f.Error == "" {
+				path := h.shortPath(f.Path)
+				fmt.Fprintf(w, "<li><a href=\"#%v\">%v</a></li>\n", ids[path], esc(path))
+			}
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+
+	fmt.Fprintf(w, "<h2>File Contents</h2>\n")
+	for _, f := range r.Files {
+		f.Path = h.shortPath(f.Path)
+		if f.Error != "" {
+			fmt.Fprintf(w, "<p class=\"error\">Error reading %v: %v</p>\n", esc(f.absPath), esc(f.Error))
+			continue
+		}
+		if f.Skipped != "" {
+			fmt.Fprintf(w, "<p id=\"%v\"><strong>%v</strong></p>\n", ids[f.Path], esc(f.header()))
+			continue
+		}
+		fmt.Fprintf(w, "<details id=\"%v\" open>\n<summary>%v</summary>\n", ids[f.Path], esc(f.header()))
+		if f.Meta != nil {
+			fmt.Fprintf(w, "<ul>\n<li>Size: %v</li>\n<li>Lines: %v</li>\n", formatSize(f.Meta.Size), f.Lines)
+			fmt.Fprintf(w, "<li>Modified: %v</li>\n<li>Mode: %v</li>\n", f.Meta.Modified.Format(time.RFC3339), esc(f.Meta.Mode))
+			fmt.Fprintf(w, "<li>Language: %v</li>\n</ul>\n", esc(f.Language))
+		}
+		class := ""
+		if f.Language != "" {
+			class = fmt.Sprintf(" class=\"language-%v\"", esc(f.Language))
+		}
+		fmt.Fprintf(w, "<pre><code%v>%v</code></pre>\n", class, esc(h.body(f)))
+		var links []string
+		for _, ref := range f.References {
+			if id, ok := ids[h.shortPath(ref)]; ok {
+				links = append(links, fmt.Sprintf("<a href=\"#%v\">%v</a>", id, esc(h.shortPath(ref))))
+			}
+		}
+		if len(links) > 0 {
+			fmt.Fprintf(w, "<p>References: %v</p>\n", strings.Join(links, ", "))
+		}
+		fmt.Fprintf(w, "</details>\n")
+	}
+	if r.Omitted > 0 {
+		fmt.Fprintf(w, "<p><em>%v more files omitted to stay within --max-tokens %v.</em></p>\n", r.Omitted, h.opts.MaxTokens)
+	}
+	if len(r.Binaries) > 0 {
+		fmt.Fprintf(w, "<h2>Binary Files</h2>\n<ul>\n")
+		for _, b := range r.Binaries {
+			fmt.Fprintf(w, "<li>%v (%v)</li>\n", esc(h.shortPath(b.Path)), formatSize(b.Size))
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+
+	if h.opts.SummaryPosition != "top" {
+		h.printSummary(w, r.Summary)
+	}
+
+	if r.Ignored != nil {
+		fmt.Fprintf(w, "<h2>Ignored Files</h2>\n<ul>\n")
+		for _, ig := range r.Ignored {
+			fmt.Fprintf(w, "<li>%v — %v</li>\n", esc(h.shortPath(ig.Path)), esc(ig.Reason))
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+	_, err := fmt.Fprintf(w, "</body>\n</html>\n")
+	return err
+}
+
+// printSummary writes the Summary section as HTML lists and a table.
+func (h htmlRenderer) printSummary(w io.Writer, s Summary) {
+	esc := html.EscapeString
+	fmt.Fprintf(w, "<h2>Summary</h2>\n<ul>\n<li>Total files: %v</li>\n", s.Files)
+	fmt.Fprintf(w, "<li>Total lines: %v (%v blank, %v comment)\n<ul>\n", s.Lines, s.Blank, s.Comment)
+	for _, l := range s.Languages {
+		fmt.Fprintf(w, "<li>%v: %v files / %v lines</li>\n", esc(l.Language), l.Files, l.Lines)
+	}
+	fmt.Fprintf(w, "</ul>\n</li>\n")
+	if h.opts.CountBlankLines {
+		fmt.Fprintf(w, "<li>Code lines: %v</li>\n<li>Comment lines: %v</li>\n<li>Blank lines: %v</li>\n", s.Code, s.Comment, s.Blank)
+	}
+	fmt.Fprintf(w, "<li>Max depth: %v</li>\n<li>Estimated tokens: %v</li>\n", s.MaxDepth, s.Tokens)
+	if o := s.GoOrigin; o != nil {
+		fmt.Fprintf(w, "<li>Go module: %v</li>\n", esc(o.Module))
+		fmt.Fprintf(w, "<li>First-party: %v files, Third-party: %v files</li>\n", o.FirstParty, o.ThirdParty)
+	}
+	fmt.Fprintf(w, "</ul>\n")
+	if len(s.Directories) > 0 {
+		fmt.Fprintf(w, "<table>\n<tr><th>Directory</th><th>Files</th><th>Lines</th></tr>\n")
+		for _, d := range s.Directories {
+			name := d.Path
+			if name != "." {
+				name = h.shortPath(name) + "/"
+			}
+			fmt.Fprintf(w, "<tr><td>%v</td><td>%v</td><td>%v</td></tr>\n", esc(name), d.Files, d.Lines)
+		}
+		fmt.Fprintf(w, "</table>\n")
+	}
+}
@@ -0,0 +1,31 @@
+package reporeader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLFormat(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":   "package main\n\nvar ok = 1 < 2 && \"<b>\" != \"\"\n",
+		"README.md": "# <demo>\n",
+	})
+	out := generate(t, Options{Path: root, Format: "html"})
+	if !strings.HasPrefix(out, "<!DOCTYPE html>\n") || !strings.HasSuffix(out, "</body>\n</html>\n") {
+		t.Errorf("not a complete HTML page:\n%s", out)
+	}
+	for _, want := range []string{
+		"<h2>Structure</h2>\n<pre>\n├── README.md\n└── main.go\n</pre>\n",
+		"<details id=\"file-1\" open>\n<summary>README.md</summary>\n<pre><code class=\"language-md\"># &lt;demo&gt;\n</code></pre>\n</details>\n",
+		"<details id=\"file-2\" open>\n<summary>main.go</summary>\n" +
+			"<pre><code class=\"language-go\">package main\n\nvar ok = 1 &lt; 2 &amp;&amp; &#34;&lt;b&gt;&#34; != &#34;&#34;\n</code></pre>\n</details>\n",
+		"<h2>Summary</h2>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("page lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<b>") || strings.Contains(out, "<demo>") {
+		t.Errorf("file content not escaped:\n%s", out)
+	}
+}
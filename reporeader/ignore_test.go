@@ -0,0 +1,248 @@
+package reporeader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ignoreCase is one path, relative to the root, and whether it should be
+// ignored.
+type ignoreCase struct {
+	path string
+	want bool
+}
+
+// checkIgnored loads the global excludes file and the .gitignore files
+// under root and checks each case.
+func checkIgnored(t *testing.T, opts Options, root string, cases []ignoreCase) {
+	t.Helper()
+	g := newGenerator(opts)
+	g.loadGlobalIgnore(root)
+	g.loadGitignores(root)
+	for _, tc := range cases {
+		path := filepath.Join(root, filepath.FromSlash(tc.path))
+		if got := g.isIgnored(path, root); got != tc.want {
+			t.Errorf("isIgnored(%q) = %v (%q), want %v", tc.path, got, g.ignoreReason(path, root), tc.want)
+		}
+	}
+}
+
+func TestGitignoreNegation(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore": "*.log\n" +
+			"!keep.log\n" +
+			"!trace.log\n" +
+			"trace.log\n" +
+			"build/\n" +
+			"!build/keep.txt\n" +
+			"docs/*\n" +
+			"!docs/public/\n",
+		"sub/.gitignore":   "!app.log\n",
+		"build/.gitignore": "!keep.txt\n",
+	})
+	checkIgnored(t, Options{}, root, []ignoreCase{
+		{"app.log", true},
+		{"keep.log", false},
+		{"nested/keep.log", false},
+		// The last matching line wins, so a later rule re-ignores.
+		{"trace.log", true},
+		// A deeper .gitignore overrides its parent.
+		{"sub/app.log", false},
+		{"sub/other.log", true},
+		// Like git, nothing inside an excluded directory can be
+		// re-included, from the same .gitignore or a deeper one.
+		{"build/x.o", true},
+		{"build/keep.txt", true},
+		// Excluding a directory's contents rather than the directory
+		// leaves room to re-include one of them.
+		{"docs/readme.md", true},
+		{"docs/private/a.md", true},
+		{"docs/public/a.md", false},
+		{"docs/public/img/b.png", false},
+	})
+}
+
+func TestExcludePatterns(t *testing.T) {
+	root := writeTree(t, map[string]string{".gitignore": "*.log\n"})
+	checkIgnored(t, Options{Exclude: []string{"testdata/", "*.generated.go", "/docs"}}, root, []ignoreCase{
+		{"testdata/a.json", true},
+		{"pkg/testdata/b.json", true},
+		{"api.generated.go", true},
+		{"pkg/api.generated.go", true},
+		{"api.go", false},
+		{"docs/a.md", true},
+		{"pkg/docs/a.md", false},
+		// .gitignore still applies alongside --exclude.
+		{"app.log", true},
+	})
+}
+
+func TestGitignoreEscapes(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore": "# a comment, not a pattern\n" +
+			"\\#notes.md\n" +
+			"\\!important.txt\n" +
+			"trailing.txt\\ \n",
+	})
+	checkIgnored(t, Options{}, root, []ignoreCase{
+		{"#notes.md", true},
+		{"notes.md", false},
+		{"# a comment, not a pattern", false},
+		{"!important.txt", true},
+		// A leading "\!" is a literal "!", not a negation.
+		{"important.txt", false},
+		{"trailing.txt ", true},
+		{"trailing.txt", false},
+	})
+}
+
+func TestGitignoreTrailingGlobstar(t *testing.T) {
+	root := writeTree(t, map[string]string{".gitignore": "logs/**\n"})
+	checkIgnored(t, Options{}, root, []ignoreCase{
+		{"logs/a.log", true},
+		{"logs/2024/01/a.log", true},
+		// The directory itself is not matched, only what it holds.
+		{"logs", false},
+		{"logs.txt", false},
+		{"src/logs/a.log", false},
+	})
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":        "*.log\n",
+		".dockerignore":     "secrets/\n!keep.log\n",
+		"web/.dockerignore": "*.map\n",
+	})
+	checkIgnored(t, Options{}, root, []ignoreCase{
+		{"app.log", true},
+		{"keep.log", true},
+		{"secrets/key.txt", false},
+		{"web/app.js.map", false},
+	})
+	// Later files override earlier ones in the same directory.
+	checkIgnored(t, Options{IgnoreFiles: []string{".gitignore", ".dockerignore"}}, root, []ignoreCase{
+		{"app.log", true},
+		{"keep.log", false},
+		{"secrets/key.txt", true},
+		{"web/app.js.map", true},
+		{"app.js.map", false},
+	})
+	checkIgnored(t, Options{IgnoreFiles: []string{".dockerignore"}}, root, []ignoreCase{
+		{"app.log", false},
+		{"secrets/key.txt", true},
+	})
+}
+
+func TestGlobalIgnore(t *testing.T) {
+	root := writeTree(t, map[string]string{".gitignore": "!keep.swp\n"})
+	cases := []ignoreCase{
+		{"a.swp", true},
+		{"pkg/b.swp", true},
+		// The repository's .gitignore takes precedence.
+		{"keep.swp", false},
+		{"a.go", false},
+	}
+
+	t.Run("xdg", func(t *testing.T) {
+		xdg := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+		if err := os.MkdirAll(filepath.Join(xdg, "git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(xdg, "git", "ignore"), []byte("*.swp\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		checkIgnored(t, Options{}, root, cases)
+	})
+
+	t.Run("core.excludesFile", func(t *testing.T) {
+		excludes := filepath.Join(t.TempDir(), "excludes")
+		if err := os.WriteFile(excludes, []byte("*.swp\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		config := filepath.Join(t.TempDir(), "gitconfig")
+		if err := os.WriteFile(config, []byte("[core]\n\texcludesFile = "+excludes+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("GIT_CONFIG_GLOBAL", config)
+		checkIgnored(t, Options{}, root, cases)
+	})
+
+	// Without a global excludes file, nothing extra is ignored.
+	checkIgnored(t, Options{}, root, []ignoreCase{{"a.swp", false}})
+}
+
+func BenchmarkIsIgnored(b *testing.B) {
+	// Every directory has its own .gitignore, so each lookup consults
+	// several levels of rules.
+	files := map[string]string{".gitignore": "*.log\nbuild/\n/dist\n"}
+	for i := range 2000 {
+		dir := fmt.Sprintf("pkg%d/sub%d", i%20, i%7)
+		files[fmt.Sprintf("pkg%d/.gitignore", i%20)] = "*.tmp\n!keep.tmp\n**/gen/*.go\n"
+		files[dir+"/.gitignore"] = "*.bak\ncache/\n"
+		files[fmt.Sprintf("%s/file%d.go", dir, i)] = "package sub\n"
+		if i%10 == 0 {
+			files[fmt.Sprintf("%s/file%d.log", dir, i)] = "log\n"
+		}
+	}
+	root := writeTree(b, files)
+	var paths []string
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+
+	b.Run("cold", func(b *testing.B) {
+		for b.Loop() {
+			// A fresh generator starts without memoized decisions.
+			g := newGenerator(Options{Path: root})
+			g.loadGitignores(root)
+			for _, path := range paths {
+				g.isIgnored(path, root)
+			}
+		}
+	})
+	b.Run("warm", func(b *testing.B) {
+		g := newGenerator(Options{Path: root})
+		g.loadGitignores(root)
+		for b.Loop() {
+			for _, path := range paths {
+				g.isIgnored(path, root)
+			}
+		}
+	})
+}
+
+func TestIgnoreFile(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":  "*.log\n",
+		"README.md":   "# readme\n",
+		"notes.md":    "notes\n",
+		"docs/a.txt":  "a\n",
+		"main.go":     "package main\n",
+		"debug.log":   "noise\n",
+		"src/deep.md": "deep\n",
+	})
+	ignoreFile := filepath.Join(t.TempDir(), ".reporeaderignore")
+	if err := os.WriteFile(ignoreFile, []byte("# docs are elsewhere\n\ndocs/\n*.md\n!README.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := generate(t, Options{Path: root, IgnoreFile: ignoreFile})
+	if got, want := fileHeaders(out), []string{".gitignore", "README.md", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("--ignore-file prints %q, want %q", got, want)
+	}
+
+	var b strings.Builder
+	err := Generate(Options{Path: root, IgnoreFile: filepath.Join(root, "missing")}, &b)
+	if err == nil || !strings.HasPrefix(err.Error(), "reading --ignore-file: ") {
+		t.Errorf("Generate with a missing --ignore-file = %v, want a read error", err)
+	}
+}
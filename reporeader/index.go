@@ -0,0 +1,120 @@
+package reporeader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// indexFile is the name of the --incremental index inside --output-dir.
+const indexFile = ".myreporeader-index.json"
+
+// fileIndex records the files rendered by one run, keyed by their path
+// relative to the root, so the next --incremental run can reuse the ones
+// that did not change. mu guards Files while files are read concurrently.
+type fileIndex struct {
+	mu sync.Mutex
+	// Options that change how a file renders; an index written under
+	// different ones is discarded.
+	Settings string                `json:"settings"`
+	Files    map[string]indexEntry `json:"files"`
+}
+
+type indexEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// SHA-256 of the file's bytes on disk
+	Hash string `json:"hash"`
+	File File   `json:"file"`
+}
+
+// indexSettings fingerprints the options, and the .gitattributes rules,
+// that affect a rendered File; loadGitattributes must have run first.
+func (g *generator) indexSettings() string {
+	o := g.opts
+	return fmt.Sprint(o.UTF8Replace, o.StrictGitattributes, o.EOLGitattributes, o.HygieneNotes, o.TrimTrailingSpace, o.MaxLines, o.PlainFence, o.TextExt, o.TextNames, g.gitattributesRules)
+}
+
+// loadIndex reads the previous run's index from --output-dir and starts a
+// new one. A missing, unreadable, or outdated index just means every file
+// is read afresh.
+func (g *generator) loadIndex() {
+	settings := g.indexSettings()
+	g.index = &fileIndex{Settings: settings, Files: map[string]indexEntry{}}
+
+	data, err := os.ReadFile(filepath.Join(g.opts.OutputDir, indexFile))
+	if err != nil {
+		return
+	}
+	var prev fileIndex
+	if json.Unmarshal(data, &prev) == nil && prev.Settings == settings {
+		g.prevIndex = &prev
+	}
+}
+
+// saveIndex writes the files rendered by this run for the next one.
+func (g *generator) saveIndex() error {
+	data, err := json.Marshal(g.index)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(g.opts.OutputDir, indexFile)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing index %s: %w", path, err)
+	}
+	return nil
+}
+
+// reuseFile returns the previous run's File for path when the file is
+// unchanged: same size and modification time, or, when data has been read,
+// the same content hash.
+func (g *generator) reuseFile(path string, relPath string, info os.FileInfo, data []byte) (File, bool) {
+	if g.prevIndex == nil || info == nil {
+		return File{}, false
+	}
+	e, ok := g.prevIndex.Files[relPath]
+	if !ok {
+		return File{}, false
+	}
+	if data == nil {
+		if e.Size != info.Size() || !e.ModTime.Equal(info.ModTime()) {
+			return File{}, false
+		}
+	} else if e.Hash != hashBytes(data) {
+		return File{}, false
+	}
+	e.Size, e.ModTime = info.Size(), info.ModTime()
+	g.index.mu.Lock()
+	g.index.Files[relPath] = e
+	g.index.mu.Unlock()
+
+	f := e.File
+	f.Status = g.gitStatuses[path]
+	f.absPath = path
+	if g.opts.Hashes {
+		f.Hash = e.Hash
+	}
+	return f, true
+}
+
+// recordFile adds a freshly rendered file to this run's index.
+func (g *generator) recordFile(f File, info os.FileInfo, data []byte) {
+	if g.index == nil || info == nil {
+		return
+	}
+	f.Status = ""
+	e := indexEntry{Size: info.Size(), ModTime: info.ModTime(), Hash: hashBytes(data), File: f}
+	g.index.mu.Lock()
+	g.index.Files[f.Path] = e
+	g.index.mu.Unlock()
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+package reporeader
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// incrementalRun generates into a --output-dir with --incremental and
+// returns the document written there.
+func incrementalRun(t *testing.T, opts Options) string {
+	t.Helper()
+	opts.Incremental = true
+	generate(t, opts)
+	data, err := os.ReadFile(filepath.Join(opts.OutputDir, "context.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// rewrite replaces the content of root/name. With keepTime set, the file
+// keeps its modification time, so only a content hash tells it changed.
+func rewrite(t *testing.T, root string, name string, content string, keepTime bool) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if keepTime {
+		if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIncrementalReuse(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.txt": "one\n", "b.txt": "two\n"})
+	opts := Options{Path: root, OutputDir: t.TempDir()}
+	incrementalRun(t, opts)
+
+	// Same size and mod time: b.txt is taken from the index unread.
+	rewrite(t, root, "b.txt", "TWO\n", true)
+	rewrite(t, root, "a.txt", "three\n", false)
+	out := incrementalRun(t, opts)
+	for _, want := range []string{"\nthree\n", "\ntwo\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestIncrementalRedecidesText(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitattributes": "*.dat text\n",
+		"x.dat":          "a\x00b\n",
+		"y.bin":          "c\x00d\n",
+		"crlf.txt":       "a\r\nb\r\n",
+	})
+	opts := Options{
+		Path:                root,
+		OutputDir:           t.TempDir(),
+		Hidden:              true,
+		TextExt:             []string{".bin"},
+		StrictGitattributes: true,
+		EOLGitattributes:    true,
+	}
+	out := incrementalRun(t, opts)
+	if got, want := fileHeaders(out), []string{".gitattributes", "crlf.txt", "x.dat", "y.bin"}; !slices.Equal(got, want) {
+		t.Fatalf("first run prints %q, want %q", got, want)
+	}
+
+	// Neither the files nor the index entries change, but the options and
+	// .gitattributes no longer make x.dat and y.bin text, and crlf.txt
+	// now has its line endings normalized.
+	opts.TextExt = nil
+	rewrite(t, root, ".gitattributes", "*.dat binary\n*.txt eol=lf\n", false)
+	out = incrementalRun(t, opts)
+	if got, want := fileHeaders(out), []string{".gitattributes", "crlf.txt"}; !slices.Equal(got, want) {
+		t.Errorf("second run prints %q, want %q", got, want)
+	}
+	if strings.Contains(out, "a\r\nb") {
+		t.Errorf("crlf.txt reused with CRLF endings:\n%q", out)
+	}
+}
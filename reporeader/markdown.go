@@ -0,0 +1,478 @@
+package reporeader
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// markdownRenderer writes the default Markdown document. When breaks is
+// set, Render records in it the offsets at which the document may be cut
+// for --split-size: before each file and each closing section.
+type markdownRenderer struct {
+	opts   Options
+	breaks *[]int64
+}
+
+func (m markdownRenderer) Render(w io.Writer, r *Report) error {
+	// --max-output-size counts everything written so far, but only ever
+	// holds back file contents.
+	cw := &countingWriter{w: w}
+	w = cw
+	full := func() bool { return m.opts.MaxOutputSize > 0 && cw.n >= m.opts.MaxOutputSize }
+	mark := func() {
+		if m.breaks != nil {
+			*m.breaks = append(*m.breaks, cw.n)
+		}
+	}
+
+	fmt.Fprintf(w, "# Repository Context\n\n")
+	fmt.Fprintf(w, "## File System Location\n\n")
+	fmt.Fprintln(w, r.Root)
+	fmt.Fprintf(w, "## Git Info\n\n")
+	if r.Git != nil {
+		fmt.Fprintf(w, "- Commit: %v\n", r.Git.Hash)
+		fmt.Fprintf(w, "- Branch: %v\n", r.Git.Branch)
+		if r.Git.Remote != "" {
+			fmt.Fprintf(w, "- Remote: %v\n", r.Git.Remote)
+		}
+		fmt.Fprintf(w, "- Author: %v\n", r.Git.Author)
+		fmt.Fprintf(w, "- Date: %v\n", r.Git.Date)
+	}
+	if m.opts.SummaryPosition == "top" && !m.opts.StructureOnly {
+		m.printSummary(w, r.Summary)
+	}
+
+	if !m.opts.NoStructure {
+		fmt.Fprintf(w, "## Structure\n\n")
+		if m.opts.StructureFormat == "mermaid" {
+			fmt.Fprintln(w, "```mermaid")
+			fmt.Fprintln(w, "graph TD")
+			fmt.Fprintf(w, "  n0[\"%v\"]\n", mermaidLabel(r.Structure.Name+"/"))
+			nextID := 1
+			printMermaid(w, r.Structure, "n0", &nextID)
+		} else {
+			fmt.Fprintln(w, "```")
+			connectors := unicodeTree
+			if m.opts.ASCII {
+				connectors = asciiTree
+			}
+			if m.opts.Indent != 0 {
+				connectors = connectors.indented(m.opts.Indent)
+			}
+			printStructure(w, r.Structure, "", connectors)
+		}
+		fmt.Fprintln(w, "```")
+	}
+	if m.opts.StructureOnly {
+		return nil
+	}
+
+	// With --combine-small, short files are held back for one shared block
+	// after the others.
+	var files, small []File
+	for _, f := range r.Files {
+		f.Path = m.shortPath(f.Path)
+		if m.opts.CombineSmall > 0 && f.Error == "" && f.Skipped == "" && !f.Hexdump && f.Lines < m.opts.CombineSmall {
+			small = append(small, f)
+		} else {
+			files = append(files, f)
+		}
+	}
+	smallHeading := fmt.Sprintf("Small files (under %v lines)", m.opts.CombineSmall)
+
+	// anchors maps each file's (displayed) path to its first heading's
+	// anchor, for --link-references.
+	var headings []string
+	anchors := map[string]string{}
+	if m.opts.TOC || m.opts.LinkReferences {
+		var owners []string
+		for _, f := range files {
+			if f.Error == "" {
+				for _, h := range m.fileHeadings(f, m.parts(f)) {
+					headings = append(headings, h)
+					owners = append(owners, f.Path)
+				}
+			}
+		}
+		if len(small) > 0 {
+			headings = append(headings, smallHeading)
+			owners = append(owners, "")
+		}
+		for i, anchor := range headingAnchors(headings) {
+			if owners[i] == "" {
+				for _, f := range small {
+					anchors[f.Path] = anchor
+				}
+			} else if _, ok := anchors[owners[i]]; !ok {
+				anchors[owners[i]] = anchor
+			}
+		}
+	}
+	if r.SharedHeader != "" {
+		fence := codeFence(r.SharedHeader)
+		fmt.Fprintf(w, "## Shared Header\n\n")
+		fmt.Fprintf(w, "Omitted from the start of each file marked \"%v\":\n", sharedHeaderNote)
+		fmt.Fprintf(w, "%v\n%v%v\n", fence, r.SharedHeader, fence)
+	}
+	if m.opts.TOC {
+		printTOC(w, headings)
+	}
+	fmt.Fprintf(w, "## File Contents\n\n")
+	emitted, truncated := 0, false
+	for _, f := range files {
+		if full() {
+			truncated = true
+			break
+		}
+		mark()
+		emitted++
+		if f.Error != "" {
+			fmt.Fprintf(w, "Error reading %s: %v\n", f.absPath, f.Error)
+			continue
+		}
+		if f.Skipped != "" {
+			fmt.Fprintf(w, "### File: %v\n", f.header())
+			continue
+		}
+		parts := m.parts(f)
+		headings := m.fileHeadings(f, parts)
+		for i, part := range parts {
+			fence := codeFence(part)
+			fmt.Fprintf(w, "### %v\n", headings[i])
+			if i == 0 && f.Meta != nil {
+				printFileMeta(w, f)
+			}
+			fmt.Fprintf(w, "%v%v\n", fence, f.Language)
+			fmt.Fprintf(w, "%v\n%v\n", part, fence)
+		}
+		var links []string
+		for _, ref := range f.References {
+			if anchor, ok := anchors[m.shortPath(ref)]; ok {
+				links = append(links, fmt.Sprintf("[%v](#%v)", m.shortPath(ref), anchor))
+			}
+		}
+		if len(links) > 0 {
+			fmt.Fprintf(w, "References: %v\n", strings.Join(links, ", "))
+		}
+	}
+	if len(small) > 0 && (truncated || full()) {
+		truncated = true
+	} else if len(small) > 0 {
+		mark()
+		emitted += len(small)
+		fmt.Fprintf(w, "### %v\n", smallHeading)
+		var contents []string
+		for _, f := range small {
+			contents = append(contents, f.Content)
+		}
+		fence := codeFence(contents...)
+		fmt.Fprintln(w, fence)
+		for _, f := range small {
+			fmt.Fprintf(w, "// === %v ===\n", f.Path)
+			fmt.Fprint(w, m.body(f))
+			if f.Content != "" && !strings.HasSuffix(f.Content, "\n") {
+				fmt.Fprintln(w)
+			}
+		}
+		fmt.Fprintln(w, fence)
+	}
+	mark()
+	if truncated {
+		fmt.Fprintf(w, "_... output truncated after %v (%v of %v files emitted, --max-output-size)._\n", formatSize(m.opts.MaxOutputSize), emitted, len(files)+len(small))
+	}
+	if r.Omitted > 0 {
+		fmt.Fprintf(w, "_%v more files omitted to stay within --max-tokens %v._\n", r.Omitted, m.opts.MaxTokens)
+	}
+	if len(r.Binaries) > 0 {
+		mark()
+		fmt.Fprintf(w, "## Binary Files\n\n")
+		for _, b := range r.Binaries {
+			fmt.Fprintf(w, "- %v (%v)\n", m.shortPath(b.Path), formatSize(b.Size))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if m.opts.SummaryPosition != "top" {
+		mark()
+		m.printSummary(w, r.Summary)
+	}
+
+	if r.Ignored != nil {
+		mark()
+		fmt.Fprintf(w, "## Ignored Files\n\n")
+		for _, ig := range r.Ignored {
+			fmt.Fprintf(w, "- %v — %v\n", m.shortPath(ig.Path), ig.Reason)
+		}
+	}
+	return nil
+}
+
+// shortPath shortens a path longer than --max-path-length by replacing its
+// middle directories with "...", keeping the leading segment, the base
+// name, and as many trailing directories as fit. A path that cannot be
+// shortened further stays longer than the limit.
+func (m markdownRenderer) shortPath(p string) string {
+	n := m.opts.MaxPathLength
+	if n <= 0 || len(p) <= n {
+		return p
+	}
+	sep := string(filepath.Separator)
+	if strings.Contains(p, "/") {
+		sep = "/"
+	}
+	segs := strings.Split(p, sep)
+	if len(segs) < 3 {
+		return p
+	}
+	tail := segs[len(segs)-1]
+	for i := len(segs) - 2; i > 0; i-- {
+		longer := segs[i] + sep + tail
+		if len(segs[0])+len(sep+"..."+sep)+len(longer) > n {
+			break
+		}
+		tail = longer
+	}
+	return segs[0] + sep + "..." + sep + tail
+}
+
+// printFileMeta writes the --include-file-metadata list for f.
+func printFileMeta(w io.Writer, f File) {
+	fmt.Fprintf(w, "- Size: %v\n", formatSize(f.Meta.Size))
+	fmt.Fprintf(w, "- Lines: %v\n", f.Lines)
+	fmt.Fprintf(w, "- Modified: %v\n", f.Meta.Modified.Format(time.RFC3339))
+	fmt.Fprintf(w, "- Mode: %v\n", f.Meta.Mode)
+	fmt.Fprintf(w, "- Language: %v\n\n", f.Language)
+}
+
+// body returns f's content as printed: numbered with --line-numbers and
+// followed by the --max-lines marker when lines were cut. The marker is
+// not a line of the file, so it is never numbered.
+func (m markdownRenderer) body(f File) string {
+	content := m.numberLines(f.Content, f.Lines)
+	if f.OmittedLines > 0 {
+		content += fmt.Sprintf("... %v lines omitted (--max-lines) ...\n", f.OmittedLines)
+	}
+	return content
+}
+
+// numberLines prefixes each line of content with its right-aligned line
+// number, e.g. " 7 | ", when --line-numbers is set. The numbers are padded
+// to the width of total, the file's full line count, so a file cut by
+// --max-lines is numbered as wide as the whole file would be.
+func (m markdownRenderer) numberLines(content string, total int) string {
+	if !m.opts.LineNumbers || content == "" {
+		return content
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	width := len(strconv.Itoa(max(len(lines), total)))
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d | %s", width, i+1, line)
+	}
+	return b.String()
+}
+
+// splitContent cuts content into parts of at most --split-large-files
+// lines, or returns it whole when the option is off or the file is short
+// enough.
+func (m markdownRenderer) splitContent(content string) []string {
+	n := m.opts.SplitLargeFiles
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if n <= 0 || len(lines) <= n {
+		return []string{content}
+	}
+	var parts []string
+	for len(lines) > n {
+		parts = append(parts, strings.TrimSuffix(strings.Join(lines[:n], ""), "\n"))
+		lines = lines[n:]
+	}
+	return append(parts, strings.Join(lines, ""))
+}
+
+// parts returns the printed body of f cut by --split-large-files.
+func (m markdownRenderer) parts(f File) []string {
+	return m.splitContent(m.body(f))
+}
+
+// fileHeadings returns the heading text for each of parts, the result of
+// m.parts(f), labeled "(part i/n)" when --split-large-files splits f.
+func (m markdownRenderer) fileHeadings(f File, parts []string) []string {
+	if len(parts) == 1 {
+		return []string{"File: " + f.header()}
+	}
+	headings := make([]string, len(parts))
+	for i := range parts {
+		headings[i] = fmt.Sprintf("File: %v (part %v/%v)", f.header(), i+1, len(parts))
+	}
+	return headings
+}
+
+// codeFence returns a backtick fence one longer than the longest backtick
+// run in contents, and at least three long, so fences inside the content
+// cannot close the block early.
+func codeFence(contents ...string) string {
+	longest, run := 0, 0
+	for _, content := range contents {
+		for _, r := range content {
+			if r != '`' {
+				run = 0
+				continue
+			}
+			run++
+			longest = max(longest, run)
+		}
+		run = 0
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// printSummary writes the Summary section, with separate code, comment,
+// and blank totals under --count-blank-lines.
+func (m markdownRenderer) printSummary(w io.Writer, s Summary) {
+	fmt.Fprintf(w, "## Summary\n- Total files: %v\n- Total lines: %v (%v blank, %v comment)\n", s.Files, s.Lines, s.Blank, s.Comment)
+	for _, l := range s.Languages {
+		fmt.Fprintf(w, "  - %v: %v files / %v lines\n", l.Language, l.Files, l.Lines)
+	}
+	if m.opts.CountBlankLines {
+		fmt.Fprintf(w, "- Code lines: %v\n- Comment lines: %v\n- Blank lines: %v\n", s.Code, s.Comment, s.Blank)
+	}
+	fmt.Fprintf(w, "- Max depth: %v\n", s.MaxDepth)
+	fmt.Fprintf(w, "- Estimated tokens: %v\n", s.Tokens)
+	if o := s.GoOrigin; o != nil {
+		fmt.Fprintf(w, "- Go module: %v\n", o.Module)
+		fmt.Fprintf(w, "- First-party: %v files, Third-party: %v files\n", o.FirstParty, o.ThirdParty)
+	}
+	if len(s.Directories) > 0 {
+		fmt.Fprintf(w, "\n| Directory | Files | Lines |\n|---|--:|--:|\n")
+		for _, d := range s.Directories {
+			name := d.Path
+			if name != "." {
+				name = m.shortPath(name) + "/"
+			}
+			fmt.Fprintf(w, "| %v | %v | %v |\n", name, d.Files, d.Lines)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// treeConnectors are the line prefixes used to draw the structure tree.
+// Large is appended to files flagged by --large-file-lines.
+type treeConnectors struct {
+	Branch, Last, Pipe, Space string
+	Large                     string
+}
+
+// mtimeLayout formats --show-mtime times: fixed width and sortable.
+const mtimeLayout = "2006-01-02 15:04"
+
+var (
+	unicodeTree = treeConnectors{"├── ", "└── ", "│   ", "    ", " ⚠ large"}
+	asciiTree   = treeConnectors{"|-- ", "`-- ", "|   ", "    ", " (large)"}
+)
+
+// indented returns c redrawn for levels width columns wide, stretching or
+// shortening the horizontal rule of the branch connectors; width is at
+// least 2.
+func (c treeConnectors) indented(width int) treeConnectors {
+	redraw := func(s string) string {
+		r := []rune(s)
+		return string(r[0]) + strings.Repeat(string(r[1]), width-2) + " "
+	}
+	pad := strings.Repeat(" ", width-1)
+	return treeConnectors{
+		Branch: redraw(c.Branch),
+		Last:   redraw(c.Last),
+		Pipe:   string([]rune(c.Pipe)[0]) + pad,
+		Space:  " " + pad,
+		Large:  c.Large,
+	}
+}
+
+// printStructure prints the children of node as a tree in the style of the
+// Unix tree command, with --dir-stats counts after directory names. prefix
+// carries the vertical bars of the ancestors that still have siblings
+// below them.
+func printStructure(w io.Writer, node *Node, prefix string, c treeConnectors) {
+	for i, child := range node.Children {
+		connector, childPrefix := c.Branch, prefix+c.Pipe
+		if i == len(node.Children)-1 {
+			connector, childPrefix = c.Last, prefix+c.Space
+		}
+		if !child.Dir {
+			suffix := ""
+			if child.Large {
+				suffix = c.Large
+			}
+			if child.Modified != nil {
+				suffix += " (" + child.Modified.Format(mtimeLayout) + ")"
+			}
+			fmt.Fprint(w, prefix, connector, child.Name, suffix, "\n")
+			continue
+		}
+		suffix := ""
+		if child.Stats != nil {
+			suffix = fmt.Sprintf(" (%v files, %v lines)", child.Stats.Files, child.Stats.Lines)
+		}
+		if child.Collapsed > 1 {
+			suffix += fmt.Sprintf(" (x%v)", child.Collapsed)
+		}
+		if child.Truncated {
+			suffix += " ..."
+		}
+		if child.Link != "" {
+			suffix += " -> " + child.Link
+		}
+		if child.Modified != nil {
+			suffix += " (" + child.Modified.Format(mtimeLayout) + ")"
+		}
+		fmt.Fprint(w, prefix, connector, child.Name, "/", suffix, "\n")
+		printStructure(w, child, childPrefix, c)
+	}
+}
+
+// printMermaid writes the children of node as Mermaid graph edges from
+// parentID, numbering nodes from *nextID.
+func printMermaid(w io.Writer, node *Node, parentID string, nextID *int) {
+	for _, child := range node.Children {
+		id := fmt.Sprintf("n%d", *nextID)
+		*nextID++
+		label := child.Name
+		if child.Dir {
+			label += "/"
+		}
+		if child.Collapsed > 1 {
+			label += fmt.Sprintf(" (x%v)", child.Collapsed)
+		}
+		if child.Truncated {
+			label += " ..."
+		}
+		if child.Link != "" {
+			label += " -> " + child.Link
+		}
+		if child.Large {
+			label += unicodeTree.Large
+		}
+		if child.Modified != nil {
+			label += " (" + child.Modified.Format(mtimeLayout) + ")"
+		}
+		fmt.Fprintf(w, "  %v --> %v[\"%v\"]\n", parentID, id, mermaidLabel(label))
+		if child.Dir {
+			printMermaid(w, child, id, nextID)
+		}
+	}
+}
+
+// mermaidLabel escapes text for use inside a quoted Mermaid node label.
+func mermaidLabel(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(text)
+}
@@ -0,0 +1,572 @@
+package reporeader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineNumbers(t *testing.T) {
+	m := markdownRenderer{opts: Options{LineNumbers: true}}
+	for _, tc := range []struct {
+		content, want string
+	}{
+		{"a\nb\n", "1 | a\n2 | b\n"},
+		// A missing final newline is not added.
+		{"a\nb", "1 | a\n2 | b"},
+		{"x\n\n", "1 | x\n2 | \n"},
+		{strings.Repeat("z\n", 10), " 1 | z\n 2 | z\n 3 | z\n 4 | z\n 5 | z\n 6 | z\n 7 | z\n 8 | z\n 9 | z\n10 | z\n"},
+		{"", ""},
+	} {
+		if got := m.numberLines(tc.content, countLines(tc.content)); got != tc.want {
+			t.Errorf("numberLines(%q) = %q, want %q", tc.content, got, tc.want)
+		}
+	}
+
+	root := writeTree(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	out := generate(t, Options{Path: root, LineNumbers: true})
+	if want := "```go\n1 | package main\n2 | \n3 | func main() {}\n\n```\n"; !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+	if out := generate(t, Options{Path: root}); !strings.Contains(out, "```go\npackage main\n") {
+		t.Errorf("lines numbered without --line-numbers:\n%s", out)
+	}
+}
+
+func TestMaxLinesMarker(t *testing.T) {
+	var long strings.Builder
+	for i := 1; i <= 120; i++ {
+		fmt.Fprintf(&long, "%d\n", i)
+	}
+	root := writeTree(t, map[string]string{"long.txt": long.String()})
+
+	out := generate(t, Options{Path: root, MaxLines: 3, LineNumbers: true})
+	// Numbers are as wide as the whole file needs, and the marker, which
+	// is not a line of the file, is left unnumbered.
+	want := "  1 | 1\n  2 | 2\n  3 | 3\n... 117 lines omitted (--max-lines) ...\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+
+	out = generate(t, Options{Path: root, MaxLines: 3, Format: "json"})
+	for _, want := range []string{`"content": "1\n2\n3\n"`, `"omittedLines": 117`, `"lines": 120`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JSON lacks %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlainFence(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"server.log": "GET / 200\n",
+		"data.csv":   "a,b\n",
+	})
+	out := generate(t, Options{Path: root})
+	for _, want := range []string{"### File: server.log\n```text\n", "### File: data.csv\n```csv\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+
+	out = generate(t, Options{Path: root, PlainFence: []string{".csv"}})
+	if want := "### File: data.csv\n```text\n"; !strings.Contains(out, want) {
+		t.Errorf("--plain-fence output lacks %q:\n%s", want, out)
+	}
+}
+
+func TestCombineSmall(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.txt":   "a\n",
+		"b.txt":   "b1\nb2\n",
+		"big.txt": "1\n2\n3\n4\n5\n",
+	})
+	out := generate(t, Options{Path: root, CombineSmall: 3})
+	if got, want := fileHeaders(out), []string{"big.txt"}; !slices.Equal(got, want) {
+		t.Errorf("separate files = %q, want %q", got, want)
+	}
+	want := "### Small files (under 3 lines)\n```\n// === a.txt ===\na\n// === b.txt ===\nb1\nb2\n```\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+}
+
+func TestMermaidStructure(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"src/a.go":     "package a\n",
+		"src/sub/c.go": "package sub\n",
+		`say "hi".txt`: "hi\n",
+	})
+	out := generate(t, Options{Path: root, StructureFormat: "mermaid"})
+	start := strings.Index(out, "```mermaid\n")
+	if start < 0 {
+		t.Fatalf("no mermaid block:\n%s", out)
+	}
+	block := out[start+len("```mermaid\n"):]
+	block = block[:strings.Index(block, "```\n")]
+	lines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+
+	if lines[0] != "graph TD" {
+		t.Errorf("first line = %q, want graph TD", lines[0])
+	}
+	node := regexp.MustCompile(`^  (?:(n\d+) --> )?(n\d+)\["([^"]*)"\]$`)
+	defined := map[string]bool{}
+	var labels []string
+	for _, line := range lines[1:] {
+		m := node.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("invalid Mermaid line %q", line)
+			continue
+		}
+		if m[1] != "" && !defined[m[1]] {
+			t.Errorf("edge from undefined node in %q", line)
+		}
+		if defined[m[2]] {
+			t.Errorf("node %s defined twice", m[2])
+		}
+		defined[m[2]] = true
+		labels = append(labels, m[3])
+	}
+	want := []string{filepath.Base(root) + "/", "say #quot;hi#quot;.txt", "src/", "a.go", "sub/", "c.go"}
+	if !slices.Equal(labels, want) {
+		t.Errorf("labels = %q, want %q", labels, want)
+	}
+}
+
+// treeFixture is a small tree for exact structure renderings.
+var treeFixture = map[string]string{
+	"docs/r.md":    "r\n",
+	"src/a.go":     "package a\n",
+	"src/sub/c.go": "package sub\n",
+	"top.txt":      "t\n",
+}
+
+// structureBlock returns the fenced block of the Structure section.
+func structureBlock(t *testing.T, doc string) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(doc, "## Structure\n\n```\n")
+	if !ok {
+		t.Fatalf("no Structure block:\n%s", doc)
+	}
+	block, _, _ := strings.Cut(rest, "```\n")
+	return block
+}
+
+func TestStructureTree(t *testing.T) {
+	root := writeTree(t, treeFixture)
+	for _, tc := range []struct {
+		ascii bool
+		want  string
+	}{
+		{false, "" +
+			"├── docs/\n" +
+			"│   └── r.md\n" +
+			"├── src/\n" +
+			"│   ├── a.go\n" +
+			"│   └── sub/\n" +
+			"│       └── c.go\n" +
+			"└── top.txt\n"},
+		{true, "" +
+			"|-- docs/\n" +
+			"|   `-- r.md\n" +
+			"|-- src/\n" +
+			"|   |-- a.go\n" +
+			"|   `-- sub/\n" +
+			"|       `-- c.go\n" +
+			"`-- top.txt\n"},
+	} {
+		got := structureBlock(t, generate(t, Options{Path: root, ASCII: tc.ascii}))
+		if got != tc.want {
+			t.Errorf("ascii=%v: structure =\n%s\nwant\n%s", tc.ascii, got, tc.want)
+		}
+	}
+}
+
+func TestStructureIndent(t *testing.T) {
+	root := writeTree(t, treeFixture)
+	for _, tc := range []struct {
+		indent int
+		ascii  bool
+		want   string
+	}{
+		// 4 is the width the default connectors already have.
+		{4, false, "" +
+			"├── docs/\n" +
+			"│   └── r.md\n" +
+			"├── src/\n" +
+			"│   ├── a.go\n" +
+			"│   └── sub/\n" +
+			"│       └── c.go\n" +
+			"└── top.txt\n"},
+		{2, false, "" +
+			"├ docs/\n" +
+			"│ └ r.md\n" +
+			"├ src/\n" +
+			"│ ├ a.go\n" +
+			"│ └ sub/\n" +
+			"│   └ c.go\n" +
+			"└ top.txt\n"},
+		{6, true, "" +
+			"|---- docs/\n" +
+			"|     `---- r.md\n" +
+			"|---- src/\n" +
+			"|     |---- a.go\n" +
+			"|     `---- sub/\n" +
+			"|           `---- c.go\n" +
+			"`---- top.txt\n"},
+	} {
+		got := structureBlock(t, generate(t, Options{Path: root, Indent: tc.indent, ASCII: tc.ascii}))
+		if got != tc.want {
+			t.Errorf("--indent %d: structure =\n%s\nwant\n%s", tc.indent, got, tc.want)
+		}
+	}
+}
+
+func TestSummaryPosition(t *testing.T) {
+	root := writeTree(t, treeFixture)
+	for _, tc := range []struct {
+		position string
+		top      bool
+	}{{"", false}, {"bottom", false}, {"top", true}} {
+		out := generate(t, Options{Path: root, SummaryPosition: tc.position})
+		summary := strings.Index(out, "## Summary\n")
+		structure := strings.Index(out, "## Structure\n")
+		if summary < 0 || structure < 0 || strings.Count(out, "## Summary\n") != 1 {
+			t.Fatalf("position %q: want one Summary and a Structure:\n%s", tc.position, out)
+		}
+		if top := summary < structure; top != tc.top {
+			t.Errorf("position %q: Summary before Structure = %v, want %v", tc.position, top, tc.top)
+		}
+		if !tc.top && strings.Contains(out[summary+1:], "\n## ") {
+			t.Errorf("position %q: Summary is not last:\n%s", tc.position, out[summary:])
+		}
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	for _, tc := range []struct {
+		contents []string
+		want     string
+	}{
+		{[]string{"no backticks\n"}, "```"},
+		{[]string{"inline `code` and ``more``\n"}, "```"},
+		{[]string{"```go\nx\n```\n"}, "````"},
+		{[]string{"````\nx\n````\n"}, "`````"},
+		{[]string{"`````\nx\n`````\n"}, "``````"},
+		// A run never continues across contents.
+		{[]string{"a``", "``b"}, "```"},
+		{[]string{"```", "`````"}, "``````"},
+	} {
+		if got := codeFence(tc.contents...); got != tc.want {
+			t.Errorf("codeFence(%q) = %q, want %q", tc.contents, got, tc.want)
+		}
+	}
+}
+
+func TestEmbeddedFences(t *testing.T) {
+	root := writeTree(t, map[string]string{"README.md": "# Usage\n\n````md\n```sh\nrun\n```\n````\n"})
+	out := generate(t, Options{Path: root})
+	want := "### File: README.md\n`````md\n# Usage\n\n````md\n```sh\nrun\n```\n````\n\n`````\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+}
+
+func TestSplitLargeFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{"n.txt": "1\n2\n3\n4\n5\n6\n7\n", "small.txt": "s\n"})
+	out := generate(t, Options{Path: root, SplitLargeFiles: 3})
+	want := []string{"n.txt (part 1/3)", "n.txt (part 2/3)", "n.txt (part 3/3)", "small.txt"}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+	for _, part := range []string{
+		"(part 1/3)\n```txt\n1\n2\n3\n```\n",
+		"(part 2/3)\n```txt\n4\n5\n6\n```\n",
+		"(part 3/3)\n```txt\n7\n",
+	} {
+		if !strings.Contains(out, part) {
+			t.Errorf("output lacks %q:\n%s", part, out)
+		}
+	}
+
+	// --max-lines cuts the file first, and its marker goes in the last part.
+	root = writeTree(t, map[string]string{"n.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"})
+	out = generate(t, Options{Path: root, MaxLines: 4, SplitLargeFiles: 2, TOC: true})
+	want = []string{"n.txt (part 1/3)", "n.txt (part 2/3)", "n.txt (part 3/3)"}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("with --max-lines, headers = %q, want %q", got, want)
+	}
+	for _, part := range []string{
+		"(part 1/3)\n```txt\n1\n2\n```\n",
+		"(part 2/3)\n```txt\n3\n4\n```\n",
+		"(part 3/3)\n```txt\n... 6 lines omitted (--max-lines) ...\n",
+		"- [n.txt (part 3/3)](#file-ntxt-part-33)\n",
+	} {
+		if !strings.Contains(out, part) {
+			t.Errorf("with --max-lines, output lacks %q:\n%s", part, out)
+		}
+	}
+}
+
+func TestFileMetadata(t *testing.T) {
+	root := writeTree(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	path := filepath.Join(root, "main.go")
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	mode := "-rw-r-----"
+	if runtime.GOOS == "windows" {
+		mode = "-rw-rw-rw-"
+	}
+
+	out := generate(t, Options{Path: root, IncludeFileMetadata: true})
+	want := "### File: main.go\n" +
+		"- Size: 29 B\n" +
+		"- Lines: 3\n" +
+		"- Modified: " + mtime.Local().Format(time.RFC3339) + "\n" +
+		"- Mode: " + mode + "\n" +
+		"- Language: go\n\n```go\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks\n%s\n%s", want, out)
+	}
+}
+
+func TestShortPath(t *testing.T) {
+	deep := "very/deeply/nested/directory/structure/file.txt"
+	for _, tc := range []struct {
+		limit int
+		path  string
+		want  string
+	}{
+		{0, deep, deep},
+		{len(deep), deep, deep},
+		{30, deep, "very/.../structure/file.txt"},
+		{40, deep, "very/.../directory/structure/file.txt"},
+		// The leading segment and base name are always kept.
+		{5, deep, "very/.../file.txt"},
+		{5, "a/b", "a/b"},
+	} {
+		m := markdownRenderer{opts: Options{MaxPathLength: tc.limit}}
+		if got := m.shortPath(tc.path); got != tc.want {
+			t.Errorf("shortPath(%q) with limit %d = %q, want %q", tc.path, tc.limit, got, tc.want)
+		}
+	}
+
+	root := writeTree(t, map[string]string{deep: "x\n"})
+	out := generate(t, Options{Path: root, MaxPathLength: 30})
+	if got, want := fileHeaders(out), []string{"very/.../structure/file.txt"}; !slices.Equal(got, want) {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+}
+
+func TestLinkReferences(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"README.md":        "See docs/setup.md and lib/util.go, not lib/util.\n",
+		"docs/setup.md":    "Run ../lib/util.go first.\n",
+		"lib/util.go":      "package lib\n",
+		"lib/unrelated.go": "package lib\n",
+	})
+	out := generate(t, Options{Path: root, LinkReferences: true})
+	for _, want := range []string{
+		"### File: README.md\n```md\nSee docs/setup.md and lib/util.go, not lib/util.\n\n```\nReferences: [docs/setup.md](#file-docssetupmd), [lib/util.go](#file-libutilgo)\n",
+		"### File: docs/setup.md\n```md\nRun ../lib/util.go first.\n\n```\nReferences: [lib/util.go](#file-libutilgo)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks\n%s\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "References:"); n != 2 {
+		t.Errorf("%d References lines, want 2:\n%s", n, out)
+	}
+}
+
+func TestCountBlankLines(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		// 4 code, 3 comment, 2 blank
+		"main.go": "// Package main.\npackage main\n\n/*\n block */\nfunc main() {\n\n\tprintln()\n}\n",
+		// 1 code, 1 comment, 1 blank
+		"run.py": "# run\n\nprint(1)\n",
+	})
+	out := generate(t, Options{Path: root, CountBlankLines: true})
+	for _, want := range []string{
+		"- Total lines: 12 (3 blank, 4 comment)\n",
+		"- Code lines: 5\n- Comment lines: 4\n- Blank lines: 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Summary lacks %q:\n%s", want, out)
+		}
+	}
+	if out := generate(t, Options{Path: root}); strings.Contains(out, "- Code lines:") {
+		t.Errorf("breakdown without --count-blank-lines:\n%s", out)
+	}
+}
+
+func TestMaxOutputSize(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	root := writeTree(t, map[string]string{
+		"a.txt": strings.Repeat(line, 10),
+		"b.txt": strings.Repeat(line, 10),
+		"c.txt": strings.Repeat(line, 10),
+	})
+
+	out := generate(t, Options{Path: root, MaxOutputSize: 1})
+	if want := "_... output truncated after 1 B (0 of 3 files emitted, --max-output-size)._\n"; !strings.Contains(out, want) {
+		t.Errorf("missing %q:\n%s", want, out)
+	}
+	if got := fileHeaders(out); len(got) != 0 {
+		t.Errorf("printed %q past the cap", got)
+	}
+	for _, want := range []string{"## Structure\n", "## Summary\n", "- Total files: 3\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("truncated output lacks %q:\n%s", want, out)
+		}
+	}
+
+	// The cap is checked between files, so the file that crosses it is
+	// still printed whole.
+	full := generate(t, Options{Path: root})
+	limit := int64(strings.Index(full, "### File: a.txt") + 1)
+	out = generate(t, Options{Path: root, MaxOutputSize: limit})
+	if got, want := fileHeaders(out), []string{"a.txt"}; !slices.Equal(got, want) {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+	if !strings.Contains(out, "(1 of 3 files emitted, --max-output-size)") {
+		t.Errorf("wrong truncation notice:\n%s", out)
+	}
+
+	if out := generate(t, Options{Path: root, MaxOutputSize: 1 << 20}); strings.Contains(out, "output truncated") {
+		t.Errorf("truncated under the cap:\n%s", out)
+	}
+}
+
+func TestPerDirSummary(t *testing.T) {
+	files := map[string]string{
+		".gitignore":   "build/\n",
+		"main.go":      "package main\n\nfunc main() {}\n",
+		"api/a.go":     strings.Repeat("// a\n", 4),
+		"api/v1/b.go":  strings.Repeat("// b\n", 3),
+		"web/x.js":     strings.Repeat("x;\n", 10),
+		"build/out.js": strings.Repeat("o;\n", 50),
+	}
+	for _, useGit := range []bool{false, true} {
+		root := writeTree(t, files)
+		if useGit {
+			gitInit(t, root)
+		}
+		for _, tc := range []struct {
+			depth int
+			want  string
+		}{
+			{1, "| web/ | 1 | 10 |\n| api/ | 2 | 7 |\n| . | 2 | 4 |\n"},
+			{2, "| web/ | 1 | 10 |\n| . | 2 | 4 |\n| api/ | 1 | 4 |\n| api/v1/ | 1 | 3 |\n"},
+		} {
+			out := generate(t, Options{Path: root, PerDirSummary: true, PerDirDepth: tc.depth})
+			want := "| Directory | Files | Lines |\n|---|--:|--:|\n" + tc.want + "\n"
+			if !strings.Contains(out, want) {
+				t.Errorf("git=%v depth %d: Summary lacks\n%s\ngot:\n%s", useGit, tc.depth, want, out)
+			}
+		}
+		if out := generate(t, Options{Path: root}); strings.Contains(out, "| Directory |") {
+			t.Errorf("git=%v: table printed without --per-dir-summary", useGit)
+		}
+	}
+}
+
+func TestListBinaries(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":       "package main\n",
+		"logo.png":      "\x89PNG\r\n\x1a\n\x00\x00",
+		"lib/blob.bin":  strings.Repeat("\x00", 3000),
+		"ignored/a.bin": "\x00",
+		".gitignore":    "ignored/\n",
+	})
+	out := generate(t, Options{Path: root, ListBinaries: true})
+	want := "## Binary Files\n\n- lib/blob.bin (2.9 KB)\n- logo.png (10 B)\n\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks\n%s\ngot:\n%s", want, out)
+	}
+	if got, want := fileHeaders(out), []string{".gitignore", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("prints %q, want %q", got, want)
+	}
+	if out := generate(t, Options{Path: root}); strings.Contains(out, "## Binary Files") {
+		t.Errorf("binaries listed without --list-binaries:\n%s", out)
+	}
+}
+
+func TestShowMtime(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"src/a.go": "package a\n",
+		"top.txt":  "t\n",
+	})
+	for name, mtime := range map[string]time.Time{
+		"src/a.go": time.Date(2024, 3, 2, 10, 0, 0, 0, time.Local),
+		"src":      time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local),
+		"top.txt":  time.Date(2024, 3, 3, 11, 15, 0, 0, time.Local),
+	} {
+		if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(name)), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "" +
+		"├── src/ (2024-03-01 09:30)\n" +
+		"│   └── a.go (2024-03-02 10:00)\n" +
+		"└── top.txt (2024-03-03 11:15)\n"
+	if got := structureBlock(t, generate(t, Options{Path: root, ShowMtime: true})); got != want {
+		t.Errorf("structure =\n%s\nwant\n%s", got, want)
+	}
+	if got := structureBlock(t, generate(t, Options{Path: root})); strings.Contains(got, "2024") {
+		t.Errorf("times shown without --show-mtime:\n%s", got)
+	}
+}
+
+func TestStructureOnly(t *testing.T) {
+	root := writeTree(t, treeFixture)
+	for _, position := range []string{"", "top"} {
+		out := generate(t, Options{Path: root, StructureOnly: true, SummaryPosition: position})
+		_, rest, ok := strings.Cut(out, "## Structure\n\n```\n")
+		if !ok {
+			t.Fatalf("no Structure:\n%s", out)
+		}
+		// Nothing follows the tree.
+		if want := "├── docs/\n│   └── r.md\n├── src/\n│   ├── a.go\n│   └── sub/\n│       └── c.go\n└── top.txt\n```\n"; rest != want {
+			t.Errorf("summary position %q: output ends with\n%s\nwant\n%s", position, rest, want)
+		}
+		if strings.Contains(out, "## Summary") {
+			t.Errorf("summary position %q: Summary printed:\n%s", position, out)
+		}
+	}
+}
+
+func TestLanguageBreakdown(t *testing.T) {
+	files := map[string]string{
+		"main.go":     strings.Repeat("// go\n", 5),
+		"pkg/util.go": strings.Repeat("// go\n", 3),
+		"README.md":   strings.Repeat("text\n", 4),
+		"Makefile":    "all:\n\tgo build\n",
+		"notes":       "plain\n",
+	}
+	want := "" +
+		"  - Go: 2 files / 8 lines\n" +
+		"  - Markdown: 1 files / 4 lines\n" +
+		"  - Makefile: 1 files / 2 lines\n" +
+		"  - misc: 1 files / 1 lines\n"
+	for _, useGit := range []bool{false, true} {
+		root := writeTree(t, files)
+		if useGit {
+			gitInit(t, root)
+		}
+		out := generate(t, Options{Path: root})
+		if !strings.Contains(out, "- Total lines: 15 (0 blank, 8 comment)\n"+want) {
+			t.Errorf("git=%v: Summary lacks the breakdown\n%s\ngot:\n%s", useGit, want, out)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package reporeader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Options selects the target and output and toggles optional sections. The
+// fields mirror the command-line flags; the zero value renders Path to w
+// with every option off. Include keys and PlainFence and TextExt entries
+// are lowercase extensions with the leading dot, e.g. ".go". Size limits are in bytes,
+// with 0 meaning no limit.
+type Options struct {
+	UTF8Replace         bool
+	ChunkByDirectory    bool
+	StrictGitattributes bool
+	// Normalize CRLF to LF in files with an eol attribute, as git stores them
+	EOLGitattributes    bool
+	CombineSmall        int
+	OutputDir           string
+	Incremental         bool
+	MaxConcurrency      int
+	Progress            bool
+	HygieneNotes        bool
+	TrimTrailingSpace   bool
+	DedupHeaders        bool
+	IncludeFileMetadata bool
+	Hashes              bool
+	PrintIgnored        bool
+	ListBinaries        bool
+	Force               bool
+	GitDateRelative     bool
+	GitStatus           bool
+	IncludeUntracked    bool
+	TOC                 bool
+	LinkReferences      bool
+	Rank                bool
+	GoDepOrder          bool
+	NoMkdir             bool
+	GoOrigin            bool
+	ExcludeGeneratedGo  bool
+	DirStats            bool
+	CollapseSimilarDirs bool
+	// Directory levels below the root's children to read; nil for no limit
+	MaxDepth            *int
+	StructureFormat     string
+	SummaryPosition     string
+	CountBlankLines     bool
+	PerDirSummary       bool
+	PerDirDepth         int
+	ASCII               bool
+	Indent              int
+	NormalizePathsPOSIX bool
+	MaxPathLength       int
+	FollowSymlinks      bool
+	Hidden              bool
+	ShowMtime           bool
+	Format              string
+	MaxOutputLines      int
+	MaxLines            int
+	MaxFileSize         int64
+	MaxOutputSize       int64
+	SplitSize           int64
+	ExcludeSizeRange    []SizeRange
+	LargeFileLines      int
+	SplitLargeFiles     int
+	LineNumbers         bool
+	MaxTokens           int
+	HexdumpExt          []string
+	MaxBinarySize       int64
+	Watch               bool
+	WatchDebounce       time.Duration
+	PlainFence          []string
+	TextExt             []string
+	TextNames           []string
+	Deny                []string
+	Include             map[string]struct{}
+	IncludeGlob         []string
+	IncludeRegex        []*regexp.Regexp
+	ExcludeRegex        []*regexp.Regexp
+	Manifest            string
+	Since               string
+	ModifiedSince       time.Time
+	Structure           string
+	StructureOnly       bool
+	NoStructure         bool
+	Exclude             []string
+	IgnoreFile          string
+	IgnoreFiles         []string
+	Output              string
+
+	// Target directory, file, or archive (the positional CLI argument)
+	Path string
+	// Target files given instead of Path, absolute or relative to the
+	// working directory or its git repository (the CLI's "-" argument).
+	// The report covers exactly these, under their common directory.
+	Files []string
+}
+
+// SizeRange is an inclusive range of file sizes in bytes for
+// --exclude-by-size-range. A negative Max leaves it unbounded above.
+type SizeRange struct {
+	Min, Max int64
+}
+
+// Contains reports whether size lies within r.
+func (r SizeRange) Contains(size int64) bool {
+	return size >= r.Min && (r.Max < 0 || size <= r.Max)
+}
+
+// Validate reports conflicting or out-of-range options so the run can fail
+// early with a descriptive error.
+func (o Options) Validate() error {
+	if o.Path == "" && len(o.Files) == 0 {
+		return errors.New("missing <path> argument")
+	}
+	if o.Path != "" && len(o.Files) > 0 {
+		return errors.New("give either a path or a list of files, not both")
+	}
+	if o.CombineSmall < 0 {
+		return fmt.Errorf("--combine-small must not be negative, got %d", o.CombineSmall)
+	}
+	if o.MaxConcurrency < 0 {
+		return fmt.Errorf("--max-concurrency must not be negative, got %d", o.MaxConcurrency)
+	}
+	switch o.StructureFormat {
+	case "", "tree", "mermaid":
+	default:
+		return fmt.Errorf("unknown --structure-format %q (want tree or mermaid)", o.StructureFormat)
+	}
+	if strings.HasPrefix(o.Since, "-") {
+		return fmt.Errorf("invalid --since ref %q", o.Since)
+	}
+	switch o.Structure {
+	case "", "full":
+	case "changed":
+		if o.Since == "" && o.ModifiedSince.IsZero() {
+			return errors.New("--structure changed requires --since or --modified-since")
+		}
+	default:
+		return fmt.Errorf("unknown --structure %q (want full or changed)", o.Structure)
+	}
+	if o.StructureOnly && o.NoStructure {
+		return errors.New("--structure-only cannot be used with --no-structure")
+	}
+	switch o.SummaryPosition {
+	case "", "top", "bottom":
+	default:
+		return fmt.Errorf("unknown --summary-position %q (want top or bottom)", o.SummaryPosition)
+	}
+	switch o.Format {
+	case "", "markdown", "json", "html":
+	default:
+		return fmt.Errorf("unknown --format %q (want markdown, json, or html)", o.Format)
+	}
+	if o.MaxLines < 0 {
+		return fmt.Errorf("--max-lines must not be negative, got %d", o.MaxLines)
+	}
+	if o.MaxOutputLines < 0 {
+		return fmt.Errorf("--max-output-lines must not be negative, got %d", o.MaxOutputLines)
+	}
+	if o.MaxFileSize < 0 {
+		return fmt.Errorf("--max-file-size must not be negative, got %d", o.MaxFileSize)
+	}
+	if o.SplitLargeFiles < 0 {
+		return fmt.Errorf("--split-large-files must not be negative, got %d", o.SplitLargeFiles)
+	}
+	if o.MaxPathLength < 0 {
+		return fmt.Errorf("--max-path-length must not be negative, got %d", o.MaxPathLength)
+	}
+	if o.LargeFileLines < 0 {
+		return fmt.Errorf("--large-file-lines must not be negative, got %d", o.LargeFileLines)
+	}
+	if o.MaxDepth != nil && *o.MaxDepth < 0 {
+		return fmt.Errorf("--max-depth must not be negative, got %d", *o.MaxDepth)
+	}
+	if o.MaxTokens < 0 {
+		return fmt.Errorf("--max-tokens must not be negative, got %d", o.MaxTokens)
+	}
+	if o.MaxBinarySize < 0 {
+		return fmt.Errorf("--max-binary-size must not be negative, got %d", o.MaxBinarySize)
+	}
+	for _, r := range o.ExcludeSizeRange {
+		if r.Min < 0 || r.Max >= 0 && r.Max < r.Min {
+			return fmt.Errorf("invalid --exclude-by-size-range %d-%d", r.Min, r.Max)
+		}
+	}
+	if o.Rank && o.GoDepOrder {
+		return errors.New("--rank and --go-dep-order cannot be used together")
+	}
+	if o.Indent < 0 || o.Indent == 1 {
+		return fmt.Errorf("--indent must be at least 2, got %d", o.Indent)
+	}
+	for _, name := range o.IgnoreFiles {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("--ignore-files takes file names, not paths, got %q", name)
+		}
+	}
+	if o.PerDirDepth < 0 {
+		return fmt.Errorf("--per-dir-depth must not be negative, got %d", o.PerDirDepth)
+	}
+	if o.MaxOutputSize < 0 {
+		return fmt.Errorf("--max-output-size must not be negative, got %d", o.MaxOutputSize)
+	}
+	if o.MaxOutputSize > 0 && (o.Format == "json" || o.Format == "html") {
+		return fmt.Errorf("--max-output-size cannot be used with --format %v; use --max-tokens to bound the files instead", o.Format)
+	}
+	if o.MaxOutputLines > 0 && o.Format == "json" {
+		return errors.New("--max-output-lines cannot be used with --format json, which must stay valid JSON")
+	}
+	if o.MaxOutputLines > 0 && o.Format == "html" {
+		return errors.New("--max-output-lines cannot be used with --format html, which must stay well-formed")
+	}
+	if o.Watch {
+		if o.WatchDebounce <= 0 {
+			return fmt.Errorf("--watch-debounce must be positive, got %v", o.WatchDebounce)
+		}
+		if isArchive(o.Path) {
+			return errors.New("--watch cannot be used with an archive target")
+		}
+	}
+	if o.Incremental && o.OutputDir == "" {
+		return errors.New("--incremental requires --output-dir")
+	}
+	if o.SplitSize < 0 {
+		return fmt.Errorf("--split-size must not be negative, got %d", o.SplitSize)
+	}
+	if o.SplitSize > 0 {
+		switch {
+		case o.Output == "" && o.OutputDir == "":
+			return errors.New("--split-size requires an output file (-o) or --output-dir")
+		case o.Format == "json" || o.Format == "html":
+			return fmt.Errorf("--split-size cannot be used with --format %v", o.Format)
+		case o.ChunkByDirectory:
+			return errors.New("--split-size cannot be used with --chunk-by-directory")
+		case o.MaxOutputLines > 0:
+			return errors.New("--split-size cannot be used with --max-output-lines")
+		case o.Watch:
+			return errors.New("--split-size cannot be used with --watch")
+		}
+	}
+	if o.ChunkByDirectory {
+		if len(o.Files) > 0 {
+			return errors.New("--chunk-by-directory cannot be used with a list of files")
+		}
+		if o.Output == "" && o.OutputDir == "" {
+			return errors.New("--chunk-by-directory requires an output file (-o) or --output-dir")
+		}
+		if !isDir(o.Path) && !isArchive(o.Path) {
+			return fmt.Errorf("--chunk-by-directory requires a directory or archive target, got file %s", o.Path)
+		}
+	}
+	return nil
+}
+
+// resolvePaths makes the target, output, and manifest paths absolute, resolving them
+// against the working directory once so later processing never depends on
+// it. A relative output file is placed inside OutputDir when one is set.
+func (o *Options) resolvePaths() error {
+	var err error
+	if len(o.Files) > 0 {
+		if err := o.resolveFiles(); err != nil {
+			return err
+		}
+	}
+	if o.Path, err = filepath.Abs(o.Path); err != nil {
+		return err
+	}
+	if o.OutputDir != "" {
+		if o.OutputDir, err = filepath.Abs(o.OutputDir); err != nil {
+			return err
+		}
+		if o.Output != "" && !filepath.IsAbs(o.Output) {
+			o.Output = filepath.Join(o.OutputDir, o.Output)
+		}
+	}
+	if o.Output != "" {
+		if o.Output, err = filepath.Abs(o.Output); err != nil {
+			return err
+		}
+	}
+	if o.Manifest != "" {
+		if o.Manifest, err = filepath.Abs(o.Manifest); err != nil {
+			return err
+		}
+	}
+	if o.IgnoreFile != "" {
+		if o.IgnoreFile, err = filepath.Abs(o.IgnoreFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveFiles makes Files absolute and sets Path to their common
+// directory. A relative path that does not exist under the working
+// directory is tried against the root of its git repository, so the output
+// of git diff --name-only works from anywhere in the checkout.
+func (o *Options) resolveFiles() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	top := ""
+	for i, f := range o.Files {
+		if filepath.IsAbs(f) {
+			o.Files[i] = filepath.Clean(f)
+			continue
+		}
+		o.Files[i] = filepath.Join(cwd, f)
+		if _, err := os.Stat(o.Files[i]); err == nil {
+			continue
+		}
+		if top == "" {
+			top = gitTopLevel(cwd)
+		}
+		if candidate := filepath.Join(top, f); top != "" {
+			if _, err := os.Stat(candidate); err == nil {
+				o.Files[i] = candidate
+			}
+		}
+	}
+
+	o.Path = filepath.Dir(o.Files[0])
+	for _, f := range o.Files[1:] {
+		for !isWithin(f, o.Path) && filepath.Dir(o.Path) != o.Path {
+			o.Path = filepath.Dir(o.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package reporeader
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	depth := -1
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"no target", Options{}, "missing <path> argument"},
+		{"path and files", Options{Path: ".", Files: []string{"a.go"}}, "give either a path or a list of files, not both"},
+		{"negative combine", Options{Path: ".", CombineSmall: -1}, "--combine-small must not be negative, got -1"},
+		{"negative depth", Options{Path: ".", MaxDepth: &depth}, "--max-depth must not be negative, got -1"},
+		{"unknown format", Options{Path: ".", Format: "xml"}, `unknown --format "xml" (want markdown, json, or html)`},
+		{"changed without since", Options{Path: ".", Structure: "changed"}, "--structure changed requires --since or --modified-since"},
+		{"rank and dep order", Options{Path: ".", Rank: true, GoDepOrder: true}, "--rank and --go-dep-order cannot be used together"},
+		{"indent 1", Options{Path: ".", Indent: 1}, "--indent must be at least 2, got 1"},
+		{"ignore file path", Options{Path: ".", IgnoreFiles: []string{"a/.ignore"}}, `--ignore-files takes file names, not paths, got "a/.ignore"`},
+		{"bad size range", Options{Path: ".", ExcludeSizeRange: []SizeRange{{Min: 10, Max: 5}}}, "invalid --exclude-by-size-range 10-5"},
+		{"incremental without dir", Options{Path: ".", Incremental: true}, "--incremental requires --output-dir"},
+		{"watch without debounce", Options{Path: ".", Watch: true}, "--watch-debounce must be positive, got 0s"},
+		{"lines cap on json", Options{Path: ".", Format: "json", MaxOutputLines: 10}, "--max-output-lines cannot be used with --format json, which must stay valid JSON"},
+		{"structure only and none", Options{Path: ".", StructureOnly: true, NoStructure: true}, "--structure-only cannot be used with --no-structure"},
+		{"split without output", Options{Path: ".", SplitSize: 1024}, "--split-size requires an output file (-o) or --output-dir"},
+		{"chunks without output", Options{Path: ".", ChunkByDirectory: true}, "--chunk-by-directory requires an output file (-o) or --output-dir"},
+	} {
+		err := tc.opts.Validate()
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s: Validate() = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	valid := Options{Path: ".", Format: "json", Watch: true, WatchDebounce: time.Second, Indent: 4}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v for valid options", err)
+	}
+}
+
+func TestRelativePathsSurviveChdir(t *testing.T) {
+	parent := writeTree(t, map[string]string{
+		"src/main.go":  "package main\n",
+		"manifest.txt": "main.go\n",
+	})
+	t.Chdir(parent)
+	opts := Options{Path: "src", Output: "out/context.md", Manifest: "manifest.txt"}
+	if err := opts.resolvePaths(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing after resolution may depend on the working directory.
+	t.Chdir(t.TempDir())
+	var b strings.Builder
+	if err := newGenerator(opts).output(&b); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(parent, "out", "context.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fileHeaders(string(data)), []string{"main.go"}; !slices.Equal(got, want) {
+		t.Errorf("prints %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package reporeader
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between two progress updates.
+const progressInterval = 100 * time.Millisecond
+
+// progress reports running file and line counts on one self-overwriting
+// line, for --progress. It writes only to its own writer (stderr), never to
+// the document. A nil *progress ignores every call, so callers need not
+// check whether --progress is on.
+type progress struct {
+	w     io.Writer
+	mu    sync.Mutex
+	phase string
+	files int
+	lines int
+	last  time.Time
+	width int // length of the line on screen, 0 when none is shown
+}
+
+func newProgress(w io.Writer) *progress {
+	return &progress{w: w}
+}
+
+// start begins a new phase such as "reading", resetting the counts.
+func (p *progress) start(phase string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase, p.files, p.lines = phase, 0, 0
+}
+
+// add counts one file of the given length, redrawing the line at most once
+// per progressInterval.
+func (p *progress) add(lines int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files++
+	p.lines += lines
+	if now := time.Now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		line := fmt.Sprintf("%v... %v files, %v lines", p.phase, p.files, shortCount(p.lines))
+		fmt.Fprintf(p.w, "\r%-*s", p.width, line)
+		p.width = len(line)
+	}
+}
+
+// done erases the progress line so later stderr messages start clean.
+func (p *progress) done() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.width > 0 {
+		fmt.Fprint(p.w, "\r"+strings.Repeat(" ", p.width)+"\r")
+		p.width = 0
+	}
+	p.last = time.Time{}
+}
+
+// shortCount abbreviates n for progress output, e.g. 52k or 1.3M.
+func shortCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 10_000:
+		return fmt.Sprintf("%vk", n/1000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
@@ -0,0 +1,57 @@
+package reporeader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var b strings.Builder
+	p := newProgress(&b)
+	p.start("reading")
+	p.add(10)
+	// Within progressInterval of the first update, so not redrawn.
+	p.add(20_000)
+	p.done()
+	line := "reading... 1 files, 10 lines"
+	if want := "\r" + line + "\r" + strings.Repeat(" ", len(line)) + "\r"; b.String() != want {
+		t.Errorf("progress wrote %q, want %q", b.String(), want)
+	}
+
+	// Without --progress the reporter is nil and every call is a no-op.
+	var off *progress
+	off.start("reading")
+	off.add(1)
+	off.done()
+}
+
+func TestShortCount(t *testing.T) {
+	for n, want := range map[int]string{
+		0:         "0",
+		9_999:     "9999",
+		52_400:    "52k",
+		1_340_000: "1.3M",
+	} {
+		if got := shortCount(n); got != want {
+			t.Errorf("shortCount(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestProgressKeepsDocumentClean(t *testing.T) {
+	root := writeTree(t, map[string]string{"main.go": "package main\n", "b.go": "package main\n"})
+	want := generate(t, Options{Path: root})
+	var got string
+	stderr := captureStderr(t, func() {
+		got = generate(t, Options{Path: root, Progress: true})
+	})
+	if got != want {
+		t.Errorf("--progress changed the document:\n%s", got)
+	}
+	if !strings.Contains(stderr, "reading... ") {
+		t.Errorf("stderr lacks progress:\n%q", stderr)
+	}
+	if !strings.HasSuffix(stderr, "\r") {
+		t.Errorf("progress line left on screen: %q", stderr)
+	}
+}
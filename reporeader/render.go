@@ -0,0 +1,40 @@
+package reporeader
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+)
+
+// JSONSchema is the JSON Schema (draft 2020-12) of the document written
+// with Format "json". It is kept by hand in schema.json, next to the types
+// in report.go it describes.
+//
+//go:embed schema.json
+var JSONSchema []byte
+
+// Renderer writes a gathered Report in one output format.
+type Renderer interface {
+	Render(w io.Writer, r *Report) error
+}
+
+// newRenderer returns the renderer selected by opts.Format.
+func newRenderer(opts Options) Renderer {
+	switch opts.Format {
+	case "json":
+		return jsonRenderer{}
+	case "html":
+		return htmlRenderer{markdownRenderer{opts: opts}}
+	}
+	return markdownRenderer{opts: opts}
+}
+
+// jsonRenderer writes the report as one indented JSON document.
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(r)
+}
@@ -0,0 +1,78 @@
+package reporeader
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	var schema struct {
+		Type       string                     `json:"type"`
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(JSONSchema, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("type = %q, want object", schema.Type)
+	}
+	for _, name := range []string{"root", "git", "structure", "files", "summary"} {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("schema lacks property %q", name)
+		}
+	}
+
+	// Every key the json format writes must be described.
+	root := writeTree(t, map[string]string{"main.go": "package main\n"})
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(generate(t, Options{Path: root, Format: "json"})), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for name := range doc {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("output key %q missing from the schema", name)
+		}
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"docs/r.md":   "# r\n",
+		"image.bin":   "\x00\x01",
+		"src/util.py": "# helpers\nx = 1\n",
+	})
+	var r Report
+	if err := json.Unmarshal([]byte(generate(t, Options{Path: root, Format: "json"})), &r); err != nil {
+		t.Fatalf("output is not a Report: %v", err)
+	}
+	if r.Root != root {
+		t.Errorf("root = %q, want %q", r.Root, root)
+	}
+	var paths []string
+	for _, f := range r.Files {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"docs/r.md", "main.go", "src/util.py"}; !slices.Equal(paths, want) {
+		t.Errorf("files = %q, want %q", paths, want)
+	}
+	if f := r.Files[1]; f.Language != "go" || f.Lines != 3 || f.Content != "package main\n\nfunc main() {}\n" {
+		t.Errorf("main.go = %+v", f)
+	}
+	if r.Summary.Files != 3 || r.Summary.Lines != 6 {
+		t.Errorf("summary = %d files, %d lines, want 3 and 6", r.Summary.Files, r.Summary.Lines)
+	}
+	var names []string
+	for _, n := range r.Structure.Children {
+		if n.Dir {
+			names = append(names, n.Name+"/")
+		} else {
+			names = append(names, n.Name)
+		}
+	}
+	if want := []string{"docs/", "image.bin", "main.go", "src/"}; !slices.Equal(names, want) {
+		t.Errorf("structure = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,1777 @@
+// Package reporeader renders a repository (or a single file) as one
+// Markdown document: location, git info, structure, file contents, and a
+// summary. The myreporeader command is a thin wrapper around Generate.
+package reporeader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	filters "github.com/whoisrgxu/myreporeader/internal/filters"
+)
+
+type Directory struct {
+	ParentPath string
+	Name       string
+	Depth      int
+}
+
+type GitInfo struct {
+	Hash   string `json:"hash"`
+	Branch string `json:"branch"`
+	Author string `json:"author"`
+	Date   string `json:"date"`
+	// https form of the origin remote, or "" when there is none
+	Remote string `json:"remote,omitempty"`
+}
+
+// A .gitignore line after comment, negation, and escape handling. Line is
+// the rule as written, for --print-ignored. Source names the per-directory
+// ignore file it came from, such as ".dockerignore" with --ignore-files.
+type ignoreRule struct {
+	Pattern string
+	Negate  bool
+	Line    string
+	Source  string
+	// Pattern compiled once for the many paths it is matched against
+	match filters.Pattern
+}
+
+// parseIgnoreLine parses one .gitignore line. Blank lines and comments yield
+// ok == false. A leading "\!" or "\#" stands for a literal "!" or "#", and
+// trailing spaces are dropped unless escaped with a backslash.
+func parseIgnoreLine(line string) (rule ignoreRule, ok bool) {
+	line = strings.TrimRight(line, "\r\t")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimSuffix(line, " ")
+	}
+	line = strings.TrimLeft(line, " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule = ignoreRule{Pattern: line, Line: line}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.Negate = true
+		rule.Pattern = line[1:]
+	case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+		rule.Pattern = line[1:]
+	}
+	rule.match = filters.Compile(rule.Pattern)
+	return rule, rule.Pattern != ""
+}
+
+// A .gitattributes line reduced to its text/binary decision and, when
+// given, its eol setting ("lf" or "crlf"). An eol setting implies text.
+type attrRule struct {
+	Pattern string
+	Text    bool
+	EOL     string
+}
+
+// generator holds the options and the state of a single run, so separate
+// Generate calls never share rules or collected output.
+type generator struct {
+	opts Options
+
+	// Per-directory .gitignore rules
+	gitignoreRules map[string][]ignoreRule
+	// Rules from the user's global excludes file, matched from the root
+	globalIgnoreRules []ignoreRule
+	globalIgnoreFile  string
+	// Rules from --ignore-file, matched from the root
+	ignoreFileRules []ignoreRule
+	// Per-directory .gitattributes rules that declare text or binary
+	gitattributesRules map[string][]attrRule
+	// Recursive per-directory counts for --dir-stats
+	dirStats map[string]*DirStat
+	// Working-tree status per absolute path, filled for --git-status
+	gitStatuses map[string]string
+	// Whether the Summary counts the files git tracks (trackedFiles, plus
+	// untracked ones with --include-untracked) rather than walking the
+	// filesystem; see loadGit
+	useGit       bool
+	trackedFiles []string
+	// Paths listed by --manifest, relative to the root; nil without one
+	manifest map[string]struct{}
+	// Absolute paths of the files changed since --since; nil without it
+	changed map[string]struct{}
+	// --incremental index being built by this run, and the previous run's
+	prevIndex, index *fileIndex
+	// Document being written by this run, or "" when writing to stdout
+	outputFile string
+	// Directory never scanned because it holds this run's output:
+	// --output-dir, or the directory of the --chunk-by-directory documents
+	// when it lies inside the target; "" for none
+	outputDir string
+	// Archive the target was unpacked from, or "" for ordinary targets. Git
+	// features are disabled while it is set.
+	archiveSource string
+	// Progress line on stderr for --progress; nil when off
+	progress *progress
+	// Memoized ignore decisions for directories, keyed by root and
+	// directory; see dirIgnoreReason
+	ignoreMu   sync.Mutex
+	dirIgnored map[[2]string]string
+	// Content sniffs and compiled patterns, kept for this run only
+	cache filters.Cache
+}
+
+func newGenerator(opts Options) *generator {
+	g := &generator{
+		opts:               opts,
+		outputDir:          opts.OutputDir,
+		gitignoreRules:     map[string][]ignoreRule{},
+		gitattributesRules: map[string][]attrRule{},
+	}
+	if opts.Progress {
+		g.progress = newProgress(os.Stderr)
+	}
+	return g
+}
+
+// Generate writes the document described by opts to opts.Output (or into
+// opts.OutputDir) when set, and to w otherwise. Relative paths in opts are
+// resolved against the working directory. With opts.Watch set, Generate
+// keeps regenerating on changes and only returns on error.
+func Generate(opts Options, w io.Writer) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := opts.resolvePaths(); err != nil {
+		return err
+	}
+	if opts.Watch {
+		return watch(opts, w)
+	}
+	return newGenerator(opts).output(w)
+}
+
+// ---------------- .gitignore handling ----------------
+
+func (g *generator) loadGitignores(root string) {
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			g.loadGitignore(path)
+		}
+		return nil
+	})
+}
+
+// loadGitignore adds the rules of dir's .gitignore, or of each of the
+// --ignore-files present in dir, in the order given so that later files
+// override earlier ones.
+func (g *generator) loadGitignore(dir string) {
+	names := g.opts.IgnoreFiles
+	if len(names) == 0 {
+		names = []string{".gitignore"}
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if rule, ok := parseIgnoreLine(line); ok {
+				rule.Source = name
+				g.gitignoreRules[dir] = append(g.gitignoreRules[dir], rule)
+			}
+		}
+	}
+	g.dirIgnored = nil
+}
+
+// targetIgnoreReason reports why a single-file target is ignored when seen
+// from the root of its git repository, so that rules for its ancestors,
+// such as node_modules/ for node_modules/foo/index.js, apply as they would
+// when scanning the whole repository. It returns "" outside a repository,
+// where only the rules of the file's own directory are known.
+func (g *generator) targetIgnoreReason(path string) string {
+	dir := filepath.Dir(path)
+	top := gitTopLevel(dir)
+	realDir, err := filepath.EvalSymlinks(dir)
+	if top == "" || err != nil || !isWithin(realDir, top) {
+		return ""
+	}
+	for d := realDir; ; d = filepath.Dir(d) {
+		if d != dir {
+			g.loadGitignore(d)
+		}
+		if d == top {
+			break
+		}
+	}
+	return g.ignoreReason(filepath.Join(realDir, filepath.Base(path)), top)
+}
+
+// loadGlobalIgnore reads the user's global excludes file, as git does for
+// every repository. A missing file leaves the layer empty.
+func (g *generator) loadGlobalIgnore(root string) {
+	path := globalExcludesFile(root)
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	g.globalIgnoreFile = path
+	for _, line := range strings.Split(string(data), "\n") {
+		if rule, ok := parseIgnoreLine(line); ok {
+			g.globalIgnoreRules = append(g.globalIgnoreRules, rule)
+		}
+	}
+	g.dirIgnored = nil
+}
+
+// loadIgnoreFile reads the --ignore-file patterns. Unlike the global
+// excludes file, a missing --ignore-file is an error.
+func (g *generator) loadIgnoreFile() error {
+	data, err := os.ReadFile(g.opts.IgnoreFile)
+	if err != nil {
+		return fmt.Errorf("reading --ignore-file: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rule, ok := parseIgnoreLine(line); ok {
+			g.ignoreFileRules = append(g.ignoreFileRules, rule)
+		}
+	}
+	g.dirIgnored = nil
+	return nil
+}
+
+// globalExcludesFile returns git's core.excludesFile setting, falling back
+// to $XDG_CONFIG_HOME/git/ignore or ~/.config/git/ignore, or "" when no
+// location can be determined.
+func globalExcludesFile(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "config", "--path", "--get", "core.excludesFile").Output()
+	if path := strings.TrimSpace(string(out)); err == nil && path != "" {
+		return path
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "git", "ignore")
+}
+
+// ---------------- .gitattributes handling ----------------
+
+func (g *generator) loadGitattributes(root string) {
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			data, err := os.ReadFile(filepath.Join(path, ".gitattributes"))
+			if err != nil {
+				return nil
+			}
+			for _, line := range strings.Split(string(data), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+					continue
+				}
+				rule := attrRule{Pattern: fields[0]}
+				decided := false
+				for _, attr := range fields[1:] {
+					switch attr {
+					case "text", "diff":
+						rule.Text, decided = true, true
+					case "binary", "-text", "-diff":
+						rule.Text, decided = false, true
+					case "eol=lf", "eol=crlf":
+						rule.Text, decided = true, true
+						rule.EOL = strings.TrimPrefix(attr, "eol=")
+					}
+				}
+				if decided {
+					g.gitattributesRules[path] = append(g.gitattributesRules[path], rule)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// gitattributeText reports whether .gitattributes declares path as text.
+// ok is false when no rule from the file's dir up to root covers it.
+func (g *generator) gitattributeText(path string, root string) (text bool, ok bool) {
+	rule, ok := g.gitattributeRule(path, root, func(attrRule) bool { return true })
+	return rule.Text, ok
+}
+
+// gitattributeEOL returns the eol attribute .gitattributes sets for path,
+// or "" when none does.
+func (g *generator) gitattributeEOL(path string, root string) string {
+	rule, _ := g.gitattributeRule(path, root, func(r attrRule) bool { return r.EOL != "" })
+	return rule.EOL
+}
+
+// gitattributeRule returns the rule that decides path among those for which
+// sets reports true. ok is false when no rule from the file's dir up to root
+// covers it. Later lines and deeper directories take precedence.
+func (g *generator) gitattributeRule(path string, root string, sets func(attrRule) bool) (rule attrRule, ok bool) {
+	dir := filepath.Dir(path)
+	for {
+		rules := g.gitattributesRules[dir]
+		relFromDir, _ := filepath.Rel(dir, path)
+		relFromDir = filepath.ToSlash(relFromDir)
+
+		for i := len(rules) - 1; i >= 0; i-- {
+			if sets(rules[i]) && g.cache.MatchPattern(relFromDir, rules[i].Pattern) {
+				return rules[i], true
+			}
+		}
+
+		if dir == root {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return attrRule{}, false
+}
+
+// isTextFile classifies path. A binary or -text attribute in
+// .gitattributes always makes it binary; a text attribute makes it text
+// only in strict mode. Otherwise --text-ext and --text-name, then extension
+// hints and sniffing decide.
+func (g *generator) isTextFile(path string, root string) bool {
+	if text, ok := g.gitattributeText(path, root); ok && (!text || g.opts.StrictGitattributes) {
+		return text
+	}
+	return g.isExtraText(path) || g.cache.IsTextFile(path)
+}
+
+// isExtraText reports whether path has one of the --text-ext extensions or
+// is named by --text-name.
+func (g *generator) isExtraText(path string) bool {
+	base := filepath.Base(path)
+	for _, name := range g.opts.TextNames {
+		if base == name {
+			return true
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(base))
+	for _, e := range g.opts.TextExt {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Check ignore using .gitignore (walking up to root) + default patterns.
+func (g *generator) isIgnored(path string, root string) bool {
+	return g.ignoreReason(path, root) != ""
+}
+
+// ignoreReason returns which rule ignores path, or "" if it is not ignored.
+func (g *generator) ignoreReason(path string, root string) string {
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(root, abs)
+	}
+	abs = filepath.Clean(abs)
+
+	// 0) Never scan our own generated output directory
+	if g.outputDir != "" && isWithin(abs, g.outputDir) {
+		return "output directory"
+	}
+
+	// Like git, a path inside an ignored directory stays ignored; a
+	// negation cannot re-include it.
+	if reason := g.dirIgnoreReason(filepath.Dir(abs), root); reason != "" {
+		return reason
+	}
+	return g.ownIgnoreReason(abs, root)
+}
+
+// dirIgnoreReason returns which rule ignores dir or one of its ancestors
+// below root, outermost first, or "". Decisions are memoized so that the
+// many files of one directory share a single walk up the tree; loading
+// more rules clears the memo.
+func (g *generator) dirIgnoreReason(dir string, root string) string {
+	if dir == root || !isWithin(dir, root) {
+		return ""
+	}
+	key := [2]string{root, dir}
+	g.ignoreMu.Lock()
+	reason, ok := g.dirIgnored[key]
+	g.ignoreMu.Unlock()
+	if ok {
+		return reason
+	}
+	reason = g.dirIgnoreReason(filepath.Dir(dir), root)
+	if reason == "" {
+		reason = g.ownIgnoreReason(dir, root)
+	}
+	g.ignoreMu.Lock()
+	if g.dirIgnored == nil {
+		g.dirIgnored = map[[2]string]string{}
+	}
+	g.dirIgnored[key] = reason
+	g.ignoreMu.Unlock()
+	return reason
+}
+
+// ownIgnoreReason applies the ignore layers to abs itself, without
+// considering whether an ancestor directory is ignored.
+func (g *generator) ownIgnoreReason(abs string, root string) string {
+	// 1) .gitignore rules from the file's dir up to root. Within a file the
+	// last matching rule wins, and deeper files override their parents.
+	negated := false
+	dir := filepath.Dir(abs)
+	for !negated {
+		rules := g.gitignoreRules[dir]
+		var relFromDir string
+		if len(rules) > 0 {
+			relFromDir, _ = filepath.Rel(dir, abs)
+			relFromDir = filepath.ToSlash(relFromDir)
+		}
+
+		for i := len(rules) - 1; i >= 0; i-- {
+			rule := rules[i]
+			if !rule.match.Match(relFromDir) {
+				continue
+			}
+			if rule.Negate {
+				negated = true
+				break
+			}
+			source, _ := filepath.Rel(root, filepath.Join(dir, rule.Source))
+			return fmt.Sprintf("%v: `%v`", filepath.ToSlash(source), rule.Line)
+		}
+
+		if dir == root {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	relFromRoot, _ := filepath.Rel(root, abs)
+	relFromRoot = filepath.ToSlash(relFromRoot)
+
+	// 2) The global excludes file and the default cross-ecosystem patterns,
+	// both relative to repo root, unless a .gitignore negation explicitly
+	// re-included the path
+	if !negated {
+		for i := len(g.globalIgnoreRules) - 1; i >= 0; i-- {
+			rule := g.globalIgnoreRules[i]
+			if !rule.match.Match(relFromRoot) {
+				continue
+			}
+			if !rule.Negate {
+				return fmt.Sprintf("%v: `%v`", g.globalIgnoreFile, rule.Line)
+			}
+			break
+		}
+		for _, pat := range filters.DefaultIgnorePatterns {
+			if g.cache.MatchPattern(relFromRoot, pat) {
+				return fmt.Sprintf("default: `%v`", pat)
+			}
+		}
+	}
+
+	// 3) --ignore-file rules and ad-hoc --exclude patterns, also relative
+	// to repo root. A negation in the ignore file only cancels its own
+	// earlier lines.
+	for i := len(g.ignoreFileRules) - 1; i >= 0; i-- {
+		rule := g.ignoreFileRules[i]
+		if !rule.match.Match(relFromRoot) {
+			continue
+		}
+		if !rule.Negate {
+			return fmt.Sprintf("%v: `%v`", g.opts.IgnoreFile, rule.Line)
+		}
+		break
+	}
+	for _, pat := range g.opts.Exclude {
+		if g.cache.MatchPattern(relFromRoot, pat) {
+			return fmt.Sprintf("--exclude: `%v`", pat)
+		}
+	}
+
+	return ""
+}
+
+// isIncluded reports whether path passes every selection filter: the
+// --include extensions (case-insensitive), the --manifest list, and the
+// --include-glob and --include-regex patterns. Each filter that is not set
+// admits everything, so the ones given intersect, and a path matching an
+// --exclude-regex never passes. Files whose size lies in an
+// --exclude-by-size-range range never pass. The output file being written
+// never passes, nor do its --split-size parts or --chunk-by-directory
+// chunks, so they are neither printed nor counted.
+func (g *generator) isIncluded(path string, root string) bool {
+	if g.isOutput(path) {
+		return false
+	}
+	if len(g.opts.ExcludeSizeRange) > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false
+		}
+		for _, r := range g.opts.ExcludeSizeRange {
+			if r.Contains(info.Size()) {
+				return false
+			}
+		}
+	}
+	if !g.opts.ModifiedSince.IsZero() && !g.modifiedSince(path) {
+		return false
+	}
+	if len(g.opts.Include) > 0 {
+		if _, ok := g.opts.Include[strings.ToLower(filepath.Ext(path))]; !ok {
+			return false
+		}
+	}
+	if g.manifest == nil && g.changed == nil && len(g.opts.IncludeGlob) == 0 &&
+		len(g.opts.IncludeRegex) == 0 && len(g.opts.ExcludeRegex) == 0 {
+		return true
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if g.manifest != nil && !g.inManifest(rel) {
+		return false
+	}
+	if _, ok := g.changed[path]; g.changed != nil && !ok {
+		return false
+	}
+	for _, re := range g.opts.ExcludeRegex {
+		if re.MatchString(rel) {
+			return false
+		}
+	}
+	if len(g.opts.IncludeRegex) > 0 {
+		matched := false
+		for _, re := range g.opts.IncludeRegex {
+			if re.MatchString(rel) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(g.opts.IncludeGlob) > 0 {
+		for _, pat := range g.opts.IncludeGlob {
+			if g.cache.MatchPattern(rel, pat) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// inManifest reports whether rel, relative to the root, is listed in the
+// --manifest or lies inside a directory that is.
+func (g *generator) inManifest(rel string) bool {
+	for p := rel; p != "."; p = path.Dir(p) {
+		if _, ok := g.manifest[p]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// loadManifest reads the --manifest file: one path relative to the root per
+// line, with blank lines and # comments skipped.
+func (g *generator) loadManifest() error {
+	data, err := os.ReadFile(g.opts.Manifest)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+	g.manifest = map[string]struct{}{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		g.manifest[path.Clean(strings.TrimPrefix(filepath.ToSlash(line), "./"))] = struct{}{}
+	}
+	return nil
+}
+
+// isCounted reports whether path is counted in the Summary and by
+// --dir-stats: a selected text file that is neither denied nor excluded
+// generated code. The deny-list is checked first, so a denied file is
+// never opened, not even to sniff it.
+func (g *generator) isCounted(path string, root string) bool {
+	return !g.isDenied(path) && g.isIncluded(path, root) && g.isTextFile(path, root) && !g.isExcludedGenerated(path)
+}
+
+// isExcludedGenerated reports whether path is generated Go code that
+// --exclude-generated-go drops from contents and counts.
+func (g *generator) isExcludedGenerated(path string) bool {
+	return g.opts.ExcludeGeneratedGo && filters.IsGeneratedGo(path)
+}
+
+// isWithin reports whether path is dir itself or lies beneath it.
+func isWithin(path string, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// ---------------- Git helpers (for accurate summary) ----------------
+
+func isGitRepo(root string) bool {
+	_, err := os.Stat(filepath.Join(root, ".git"))
+	return err == nil
+}
+
+// listedFiles returns the Files option without the entries that are
+// missing, not regular files, or ignored, warning about each on stderr.
+func (g *generator) listedFiles(root string) []string {
+	var paths []string
+	for _, path := range g.opts.Files {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", path, err)
+		case !info.Mode().IsRegular():
+			fmt.Fprintf(os.Stderr, "Skipping %s: not a regular file\n", path)
+		case !g.opts.Force && g.isIgnored(path, root):
+			fmt.Fprintf(os.Stderr, "Skipping %s: ignored by %v\n", path, g.ignoreReason(path, root))
+		default:
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// loadChanged fills g.changed with the files under root that differ
+// between --since and HEAD, per git diff --name-only <ref>...HEAD. Deleted
+// files are listed by git but simply never match a file on disk.
+func (g *generator) loadChanged(root string) error {
+	top := gitTopLevel(root)
+	if top == "" || g.archiveSource != "" {
+		return fmt.Errorf("--since requires a git repository, and %s is not in one", root)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", root, "diff", "--name-only", "-z", g.opts.Since+"...HEAD")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+		return fmt.Errorf("git diff %s...HEAD: %v: %s", g.opts.Since, err, msg)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	sub, err := filepath.Rel(top, realRoot)
+	if err != nil {
+		return err
+	}
+	g.changed = map[string]struct{}{}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+		rel, err := filepath.Rel(sub, filepath.FromSlash(name))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		g.changed[filepath.Join(root, rel)] = struct{}{}
+	}
+	return nil
+}
+
+// modifiedSince reports whether path was modified after --modified-since.
+func (g *generator) modifiedSince(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.ModTime().After(g.opts.ModifiedSince)
+}
+
+// hasChanged reports whether a --since change lies at or below path.
+func (g *generator) hasChanged(path string) bool {
+	for p := range g.changed {
+		if isWithin(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitTopLevel returns the root of the git work tree containing dir, or ""
+// outside one.
+func gitTopLevel(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func listGitTrackedFiles(root string, untracked bool) ([]string, error) {
+	args := []string{"-C", root, "ls-files", "-z"}
+	if untracked {
+		// Also list files git does not ignore but has not been told about
+		args = append(args, "--cached", "--others", "--exclude-standard")
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	parts := bytes.Split(out, []byte{0})
+	files := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if len(p) == 0 {
+			continue
+		}
+		files = append(files, filepath.Join(root, string(p)))
+	}
+	return files, nil
+}
+
+// langCounts accumulates the per-language file and line counts for the
+// Summary breakdown and, when depth is set, the --per-dir-summary
+// subtotals of the directories depth levels below location. It is safe for
+// concurrent use.
+type langCounts struct {
+	mu    sync.Mutex
+	stats map[string]*LangStat
+
+	location string
+	depth    int
+	dirs     map[string]*DirSummary
+}
+
+func (c *langCounts) add(path string, lines lineCount) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		c.stats = map[string]*LangStat{}
+	}
+	name := filters.LanguageName(path)
+	st := c.stats[name]
+	if st == nil {
+		st = &LangStat{Language: name}
+		c.stats[name] = st
+	}
+	st.Files++
+	st.Lines += lines.Lines
+	st.Blank += lines.Blank
+	st.Comment += lines.Comment
+
+	if c.depth > 0 {
+		if c.dirs == nil {
+			c.dirs = map[string]*DirSummary{}
+		}
+		dir := c.dirPrefix(path)
+		ds := c.dirs[dir]
+		if ds == nil {
+			ds = &DirSummary{Path: dir}
+			c.dirs[dir] = ds
+		}
+		ds.Files++
+		ds.Lines += lines.Lines
+	}
+}
+
+// dirPrefix returns the directory path's subtotal goes to: its first depth
+// directories below location, slash-separated, or "." for files directly
+// in location.
+func (c *langCounts) dirPrefix(path string) string {
+	rel, err := filepath.Rel(c.location, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return "."
+	}
+	segs := strings.Split(filepath.ToSlash(rel), "/")
+	if len(segs) > c.depth {
+		segs = segs[:c.depth]
+	}
+	return strings.Join(segs, "/")
+}
+
+// sortedDirs returns the --per-dir-summary subtotals with the most lines
+// first, or nil when they were not collected.
+func (c *langCounts) sortedDirs() []DirSummary {
+	if c.dirs == nil {
+		return nil
+	}
+	list := make([]DirSummary, 0, len(c.dirs))
+	for _, ds := range c.dirs {
+		list = append(list, *ds)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Lines != list[j].Lines {
+			return list[i].Lines > list[j].Lines
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
+
+// sorted returns the counts with the most lines first.
+func (c *langCounts) sorted() []LangStat {
+	list := make([]LangStat, 0, len(c.stats))
+	for _, st := range c.stats {
+		list = append(list, *st)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Lines != list[j].Lines {
+			return list[i].Lines > list[j].Lines
+		}
+		return list[i].Language < list[j].Language
+	})
+	return list
+}
+
+// countFilesAndLinesGit counts the tracked files (see loadGit) under the
+// directory "under", which is root itself or one of its descendants,
+// adding each to langs.
+func (g *generator) countFilesAndLinesGit(root string, under string, langs *langCounts) (int, int) {
+	var fileCount, lineCount int64
+
+	// Sniffing and line counting share one worker budget.
+	g.forEachConcurrent(g.trackedFiles, func(f string) {
+		if !isWithin(f, under) {
+			return
+		}
+		if !g.withinMaxDepth(f, under) || g.isIgnored(f, root) || !g.isCounted(f, root) {
+			return
+		}
+		lines, err := g.countFileLines(f, root)
+		if err != nil {
+			return
+		}
+		atomic.AddInt64(&fileCount, 1)
+		atomic.AddInt64(&lineCount, int64(lines.Lines))
+		langs.add(f, lines)
+		g.progress.add(lines.Lines)
+	})
+	return int(fileCount), int(lineCount)
+}
+
+// forEachConcurrent calls fn for every path using up to
+// opts.MaxConcurrency goroutines; a limit of 1 or less runs serially.
+func (g *generator) forEachConcurrent(paths []string, fn func(string)) {
+	if g.opts.MaxConcurrency <= 1 {
+		for _, p := range paths {
+			fn(p)
+		}
+		return
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < g.opts.MaxConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				fn(p)
+			}
+		}()
+	}
+	for _, p := range paths {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// loadGitStatus maps files with uncommitted changes to "untracked",
+// "staged", "modified", or "staged, modified" using git status --porcelain.
+// Clean tracked files are absent from the map.
+func loadGitStatus(root string) (map[string]string, error) {
+	topOut, err := exec.Command("git", "-C", root, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, err
+	}
+	top := strings.TrimSpace(string(topOut))
+
+	out, err := exec.Command("git", "-C", root, "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return nil, err
+	}
+	statuses := map[string]string{}
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], filepath.Join(top, filepath.FromSlash(entry[3:]))
+		if x == 'R' || x == 'C' {
+			i++ // the next field is the rename/copy source
+		}
+		var labels []string
+		switch {
+		case x == '?' && y == '?':
+			labels = append(labels, "untracked")
+		default:
+			if x != ' ' {
+				labels = append(labels, "staged")
+			}
+			if y != ' ' {
+				labels = append(labels, "modified")
+			}
+		}
+		statuses[path] = strings.Join(labels, ", ")
+	}
+	return statuses, nil
+}
+
+// ---------------- Core FS helpers ----------------
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.IsDir()
+}
+
+// newVisited returns the visited set for one walk starting at location.
+func newVisited(location string) map[string]bool {
+	visited := map[string]bool{}
+	if real, err := filepath.EvalSymlinks(location); err == nil {
+		visited[real] = true
+	}
+	return visited
+}
+
+// enterDir reports whether a walk should descend into the directory at
+// path. Symlinked directories are skipped unless --follow-symlinks is set,
+// and then each resolved directory is entered once, so a link back to an
+// ancestor cannot loop.
+func (g *generator) enterDir(path string, visited map[string]bool) bool {
+	if !g.opts.FollowSymlinks {
+		info, err := os.Lstat(path)
+		return err == nil && info.IsDir()
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil || visited[real] {
+		return false
+	}
+	visited[real] = true
+	return true
+}
+
+func (d Directory) getPath() string {
+	return filepath.Join(d.ParentPath, d.Name)
+}
+
+// childPaths returns the non-hidden, non-ignored entries of d as full paths.
+func (g *generator) childPaths(d Directory, root string) []string {
+	var paths []string
+	for _, entry := range g.getNonHiddenEntries(d.listEntries()) {
+		childPath := filepath.Join(d.getPath(), entry.Name())
+		if g.isIgnored(childPath, root) {
+			continue
+		}
+		paths = append(paths, childPath)
+	}
+	return paths
+}
+
+func (d Directory) readEntries() ([]os.DirEntry, error) {
+	return os.ReadDir(d.getPath())
+}
+
+// listEntries reads d's entries, logging a failure to stderr and returning
+// what could be read, so one unreadable directory doesn't abort the report.
+func (d Directory) listEntries() []os.DirEntry {
+	entries, err := d.readEntries()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading dir %s: %v\n", d.getPath(), err)
+	}
+	return entries
+}
+
+// lineCount is the result of counting one file's lines. Blank and
+// Comment are subsets of Lines; comments are detected per language with
+// filters.CommentSyntaxFor, so files of unknown languages have none.
+type lineCount struct {
+	Lines, Blank, Comment int
+}
+
+// countFileLines counts path's lines as countLinesInFile does, reading it
+// with LF line endings when --respect-eol-gitattributes applies to it, so
+// the counts come from the same contents that are printed.
+func (g *generator) countFileLines(path string, root string) (lineCount, error) {
+	return countLinesInFile(path, g.opts.EOLGitattributes && g.gitattributeEOL(path, root) != "")
+}
+
+// Robust line counter (handles long lines). With normalizeEOL, each
+// "\r\n" ending is read as "\n", the way git stores files that have a
+// .gitattributes eol attribute.
+func countLinesInFile(path string, normalizeEOL bool) (lineCount, error) {
+	var c lineCount
+	file, err := os.Open(path)
+	if err != nil {
+		return c, err
+	}
+	defer file.Close()
+
+	syntax, hasSyntax := filters.CommentSyntaxFor(path)
+	inBlock := false
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		// A final line without a newline still counts, as in countLines.
+		if err == io.EOF && line == "" {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return c, err
+		}
+		if normalizeEOL && strings.HasSuffix(line, "\r\n") {
+			line = line[:len(line)-2] + "\n"
+		}
+		c.Lines++
+		if strings.TrimSpace(line) == "" {
+			c.Blank++
+			continue
+		}
+		if hasSyntax {
+			var comment bool
+			comment, inBlock = syntax.IsComment(line, inBlock)
+			if comment {
+				c.Comment++
+			}
+		}
+	}
+	return c, nil
+}
+
+// countFilesAndLines counts the text files at or below paths with an
+// ignore-aware walk, adding each to langs. paths are the entries of the
+// target directory (or the target files), and directories are read down to
+// --max-depth as collect reads them. visited tracks the directories
+// entered, see enterDir.
+func (g *generator) countFilesAndLines(paths []string, root string, langs *langCounts, visited map[string]bool) (int, int) {
+	// Walk first, then sniff and count the files found with up to
+	// --max-concurrency workers.
+	var files []string
+	var gather func(paths []string, depth int)
+	gather = func(paths []string, depth int) {
+		for _, path := range paths {
+			if g.isIgnored(path, root) {
+				continue
+			}
+			if !isDir(path) {
+				files = append(files, path)
+				continue
+			}
+			if g.beyondMaxDepth(depth) || !g.enterDir(path, visited) {
+				continue
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading dir %s: %v\n", path, err)
+				continue
+			}
+			var children []string
+			for _, entry := range entries {
+				if g.isHidden(entry.Name()) {
+					continue
+				}
+				children = append(children, filepath.Join(path, entry.Name()))
+			}
+			gather(children, depth+1)
+		}
+	}
+	gather(paths, 0)
+
+	var fileCount, lineCount int64
+	g.forEachConcurrent(files, func(path string) {
+		if !g.isCounted(path, root) {
+			return
+		}
+		lines, err := g.countFileLines(path, root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error counting lines in %s: %v\n", path, err)
+			return
+		}
+		atomic.AddInt64(&fileCount, 1)
+		atomic.AddInt64(&lineCount, int64(lines.Lines))
+		langs.add(path, lines)
+		g.progress.add(lines.Lines)
+	})
+	return int(fileCount), int(lineCount)
+}
+
+// textContent returns the printable content of data. Invalid UTF-8 is
+// rejected unless --utf8-replace is set, in which case invalid sequences
+// are replaced with U+FFFD.
+func (g *generator) textContent(data []byte) (string, bool) {
+	if utf8.Valid(data) {
+		return string(data), true
+	}
+	if !g.opts.UTF8Replace {
+		return "", false
+	}
+	return strings.ToValidUTF8(string(data), "\uFFFD"), true
+}
+
+// countLines counts the lines in content, including a final line without a
+// trailing newline.
+func countLines(content string) int {
+	n := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		n++
+	}
+	return n
+}
+
+// trimTrailingWhitespace removes spaces and tabs from the end of every
+// line of content, keeping CRLF line endings and the line count intact.
+func trimTrailingWhitespace(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+		if cr {
+			line += "\r"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headLines returns the first n lines of content, which must have more.
+func headLines(content string, n int) string {
+	end := 0
+	for range n {
+		end += strings.IndexByte(content[end:], '\n') + 1
+	}
+	return content[:end]
+}
+
+// hygieneNotes flags trailing whitespace and a missing final newline.
+func hygieneNotes(content string) []string {
+	var notes []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimRight(line, " \t") != line {
+			notes = append(notes, "trailing whitespace")
+			break
+		}
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		notes = append(notes, "no final newline")
+	}
+	return notes
+}
+
+// collectDirStats aggregates file and line counts bottom-up for every
+// directory under location, selecting files exactly as the Summary does so
+// that the totals agree.
+func (g *generator) collectDirStats(root string, location string) map[string]*DirStat {
+	stats := map[string]*DirStat{}
+	add := func(path string) {
+		lines, err := g.countFileLines(path, root)
+		if err != nil {
+			return
+		}
+		for dir := filepath.Dir(path); isWithin(dir, location); dir = filepath.Dir(dir) {
+			if stats[dir] == nil {
+				stats[dir] = &DirStat{}
+			}
+			stats[dir].Files++
+			stats[dir].Lines += lines.Lines
+			if dir == location {
+				break
+			}
+		}
+	}
+
+	if g.useGit {
+		for _, f := range g.trackedFiles {
+			if isWithin(f, location) && g.withinMaxDepth(f, location) && !g.isIgnored(f, root) && g.isCounted(f, root) {
+				add(f)
+			}
+		}
+		return stats
+	}
+
+	visited := newVisited(location)
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			hidden := g.isHidden(entry.Name())
+			if hidden || g.isIgnored(path, root) {
+				continue
+			}
+			if isDir(path) {
+				if !g.beyondMaxDepth(depth) && g.enterDir(path, visited) {
+					walk(path, depth+1)
+				}
+				continue
+			}
+			if g.isCounted(path, root) {
+				add(path)
+			}
+		}
+	}
+	walk(location, 0)
+	return stats
+}
+
+// beyondMaxDepth reports whether the subdirectories of a directory depth
+// levels below the target are past --max-depth and so are not read.
+func (g *generator) beyondMaxDepth(depth int) bool {
+	return g.opts.MaxDepth != nil && depth >= *g.opts.MaxDepth
+}
+
+// withinMaxDepth reports whether the file at path lies in a directory that
+// --max-depth lets a walk from location read.
+func (g *generator) withinMaxDepth(path string, location string) bool {
+	if g.opts.MaxDepth == nil {
+		return true
+	}
+	rel, err := filepath.Rel(location, filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	depth := 0
+	if rel != "." {
+		depth = strings.Count(rel, string(filepath.Separator)) + 1
+	}
+	return depth <= *g.opts.MaxDepth
+}
+
+// isHidden reports whether an entry named name is left out as a dotfile:
+// anything starting with "." except .gitignore, or with --hidden only the
+// .git directory.
+func (g *generator) isHidden(name string) bool {
+	if g.opts.Hidden {
+		return name == ".git"
+	}
+	return strings.HasPrefix(name, ".") && name != ".gitignore"
+}
+
+// getNonHiddenEntries drops hidden entries (see isHidden) and sorts the
+// rest purely lexically by name, files and directories mixed, so the
+// structure and file order never depend on the filesystem.
+func (g *generator) getNonHiddenEntries(entries []os.DirEntry) []os.DirEntry {
+	var result []os.DirEntry
+	for _, e := range entries {
+		if g.isHidden(e.Name()) {
+			continue
+		}
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result
+}
+
+// identifyFileType returns the fence language for path, rendering
+// --plain-fence extensions as plain text and falling back to the shebang
+// of extensionless scripts.
+func (g *generator) identifyFileType(path string, content string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, plain := range g.opts.PlainFence {
+		if ext == plain {
+			return "text"
+		}
+	}
+	if lang := filters.DetectLanguage(path, content); lang != "" {
+		return lang
+	}
+	return filters.LanguageHint(path)
+}
+
+// isDenied reports whether path matches the built-in deny-list or --deny.
+func (g *generator) isDenied(path string) bool {
+	return g.cache.IsDenied(path, g.opts.Deny...)
+}
+
+// ---------------- Git info ----------------
+
+// GetLatestCommit returns the last commit touching d's repository, with
+// the date in relative form ("3 days ago") when relativeDate is set.
+func (d Directory) GetLatestCommit(relativeDate bool) (*GitInfo, error) {
+	// Fields are separated by the ASCII unit separator, which cannot
+	// appear in author names the way "|" or "," can.
+	logArgs := []string{"-C", d.ParentPath, "log", "-1", "--pretty=format:%H%x1f%an%x1f%ad"}
+	if relativeDate {
+		logArgs = append(logArgs, "--date=relative")
+	}
+	cmd := exec.Command("git", logArgs...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(out.String(), "\x1f", 3)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("unexpected git log format")
+	}
+
+	branchCmd := exec.Command("git", "-C", d.ParentPath, "rev-parse", "--abbrev-ref", "HEAD")
+	var branchOut bytes.Buffer
+	branchCmd.Stdout = &branchOut
+	if err := branchCmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return &GitInfo{
+		Hash:   parts[0],
+		Author: parts[1],
+		Date:   parts[2],
+		Branch: strings.TrimSpace(branchOut.String()),
+		Remote: originURL(d.ParentPath),
+	}, nil
+}
+
+// originURL returns the origin remote of the repository containing dir,
+// normalized by normalizeRemoteURL, or "" if there is no such remote.
+func originURL(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return normalizeRemoteURL(strings.TrimSpace(string(out)))
+}
+
+// normalizeRemoteURL rewrites ssh, git, and scp-style remotes such as
+// git@github.com:user/repo.git to https://github.com/user/repo, dropping
+// credentials and a trailing ".git". Local paths are returned unchanged.
+func normalizeRemoteURL(raw string) string {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(raw, "/"), ".git")
+	if strings.Contains(trimmed, "://") {
+		u, err := url.Parse(trimmed)
+		if err != nil || u.Host == "" {
+			return raw
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			// ssh and git ports do not carry over to https
+			u.Host = u.Hostname()
+		}
+		u.Scheme = "https"
+		u.User = nil
+		return u.String()
+	}
+
+	// scp-like syntax: [user@]host:path, where the host has no slash
+	colon := strings.Index(trimmed, ":")
+	if colon <= 0 || strings.Contains(trimmed[:colon], "/") {
+		return raw
+	}
+	host := trimmed[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	if len(host) == 1 {
+		return raw // Windows drive letter, e.g. C:\repo
+	}
+	return "https://" + host + "/" + strings.TrimPrefix(trimmed[colon+1:], "/")
+}
+
+// ---------------- Main output ----------------
+
+// output resolves the target, prepares the output location, and writes
+// the document (or the chunks) there, or to w when no output file is set.
+func (g *generator) output(w io.Writer) error {
+	var folderPath string
+	var outputPath string
+	var filePaths []string
+
+	// All paths are absolute by now (see resolvePaths), so nothing below
+	// depends on the process working directory.
+	targetPath := g.opts.Path
+
+	if !isDir(targetPath) && isArchive(targetPath) {
+		extracted, err := extractArchive(targetPath)
+		if err != nil {
+			return fmt.Errorf("reading archive %s: %w", targetPath, err)
+		}
+		defer os.RemoveAll(extracted)
+		g.archiveSource = targetPath
+		targetPath = extracted
+	}
+
+	if isDir(targetPath) {
+		folderPath = targetPath
+		filePaths = nil
+		g.loadGitignores(folderPath)
+	} else {
+		folderPath = filepath.Dir(targetPath)
+		filePaths = []string{targetPath}
+		g.loadGitignores(folderPath)
+	}
+	g.loadGlobalIgnore(folderPath)
+	if g.opts.IgnoreFile != "" {
+		if err := g.loadIgnoreFile(); err != nil {
+			return err
+		}
+	}
+	if filePaths != nil && len(g.opts.Files) == 0 && g.archiveSource == "" && !g.opts.Force {
+		if reason := g.targetIgnoreReason(targetPath); reason != "" {
+			return fmt.Errorf("%s is ignored by %v; use --force to print it anyway", targetPath, reason)
+		}
+	}
+	g.loadGitattributes(folderPath)
+	if g.opts.Manifest != "" {
+		if err := g.loadManifest(); err != nil {
+			return err
+		}
+	}
+	if g.opts.Since != "" {
+		if err := g.loadChanged(folderPath); err != nil {
+			return err
+		}
+	}
+	if len(g.opts.Files) > 0 {
+		if filePaths = g.listedFiles(folderPath); len(filePaths) == 0 {
+			return errors.New("none of the listed files can be read")
+		}
+	}
+
+	dir := Directory{
+		ParentPath: folderPath,
+		Name:       "",
+	}
+
+	outputPath = g.opts.Output
+	if g.opts.OutputDir != "" {
+		if err := os.MkdirAll(g.opts.OutputDir, 0o755); err != nil {
+			return err
+		}
+		if outputPath == "" {
+			name := "context.md"
+			switch g.opts.Format {
+			case "json":
+				name = "context.json"
+			case "html":
+				name = "context.html"
+			}
+			outputPath = filepath.Join(g.opts.OutputDir, name)
+		}
+	}
+	g.outputFile = outputPath
+	if outDir := filepath.Dir(outputPath); g.opts.ChunkByDirectory && g.outputDir == "" && outDir != folderPath && isWithin(outDir, folderPath) {
+		g.outputDir = outDir
+		g.dirIgnored = nil
+	}
+
+	if outputPath != "" {
+		outDir := filepath.Dir(outputPath)
+		if g.opts.NoMkdir {
+			if !isDir(outDir) {
+				return fmt.Errorf("output directory %s does not exist (--no-mkdir)", outDir)
+			}
+		} else if err := os.MkdirAll(outDir, 0o755); err != nil {
+			return fmt.Errorf("creating output directory %s: %w", outDir, err)
+		}
+	}
+
+	if g.opts.Incremental {
+		g.loadIndex()
+	}
+
+	if g.opts.ChunkByDirectory {
+		g.writeChunks(folderPath, outputPath)
+	} else if g.opts.SplitSize > 0 {
+		if err := g.writeParts(outputPath, folderPath, dir, filePaths); err != nil {
+			return err
+		}
+	} else {
+		var f io.WriteCloser
+		if outputPath != "" {
+			var err error
+			if f, err = createOutput(outputPath); err != nil {
+				return fmt.Errorf("creating output file %s: %w", outputPath, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := g.writeDocument(w, folderPath, dir, filePaths); err != nil {
+			return err
+		}
+		if f != nil {
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("writing output file %s: %w", outputPath, err)
+			}
+		}
+	}
+
+	if g.index != nil {
+		return g.saveIndex()
+	}
+	return nil
+}
+
+// createOutput creates the output file at path. When path ends in .gz,
+// what is written is gzip-compressed; closing the returned writer then
+// finishes the compressed stream before closing the file.
+func createOutput(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	return &gzipFile{Writer: gzip.NewWriter(f), file: f}, nil
+}
+
+// gzipFile is a gzip stream written to file.
+type gzipFile struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Writer.Close()
+	if cerr := g.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeChunks writes one self-contained document per top-level directory of
+// root. Chunk files are named after outputPath with the directory name
+// inserted before the extension, e.g. out.md -> out.src.md and
+// out.md.gz -> out.src.md.gz. The files directly in root go into
+// outputPath itself, whose Structure still shows the whole tree.
+func (g *generator) writeChunks(root, outputPath string) {
+	rootDir := Directory{ParentPath: root}
+	base, ext := splitOutputName(outputPath)
+
+	var topFiles []string
+	for _, entry := range g.getNonHiddenEntries(rootDir.listEntries()) {
+		path := filepath.Join(root, entry.Name())
+		if g.isIgnored(path, root) {
+			continue
+		}
+		if !entry.IsDir() {
+			if !isDir(path) {
+				topFiles = append(topFiles, path)
+			}
+			continue
+		}
+		dir := Directory{ParentPath: root, Name: entry.Name()}
+		g.writeChunk(base+"."+entry.Name()+ext, root, dir, nil)
+	}
+	if len(topFiles) > 0 {
+		g.writeChunk(outputPath, root, rootDir, topFiles)
+	}
+}
+
+// writeChunk writes one --chunk-by-directory document to name, reporting
+// failures on stderr so the other chunks are still written.
+func (g *generator) writeChunk(name string, root string, dir Directory, filePaths []string) {
+	f, err := createOutput(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating chunk %s: %v\n", name, err)
+		return
+	}
+	if err := g.writeDocument(f, root, dir, filePaths); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing chunk %s: %v\n", name, err)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing chunk %s: %v\n", name, err)
+	}
+}
+
+// isOutput reports whether path is the output file being written or one
+// of its --split-size parts or --chunk-by-directory chunks.
+func (g *generator) isOutput(path string) bool {
+	return g.outputFile != "" && (path == g.outputFile ||
+		g.opts.SplitSize > 0 && isOutputPart(path, g.outputFile) ||
+		g.opts.ChunkByDirectory && isChunkOutput(path, g.outputFile))
+}
+
+// isChunkOutput reports whether path is one of the --chunk-by-directory
+// documents of outputPath, e.g. out.src.md for out.md, including those of
+// directories that no longer exist.
+func isChunkOutput(path, outputPath string) bool {
+	base, ext := splitOutputName(outputPath)
+	rest, ok := strings.CutPrefix(path, base+".")
+	if !ok {
+		return false
+	}
+	name, ok := strings.CutSuffix(rest, ext)
+	return ok && name != "" && !strings.ContainsRune(name, filepath.Separator)
+}
+
+// splitOutputName splits an output path into the part before its
+// extension and the extension, counting ".md.gz" as one extension.
+func splitOutputName(outputPath string) (base, ext string) {
+	ext = filepath.Ext(outputPath)
+	if ext == ".gz" {
+		ext = filepath.Ext(strings.TrimSuffix(outputPath, ext)) + ext
+	}
+	return strings.TrimSuffix(outputPath, ext), ext
+}
+
+// partHeaderReserve is the room --split-size keeps in each part for its
+// "_Part i of n_" header.
+const partHeaderReserve = 32
+
+// writeParts writes the Markdown document for dir as numbered parts of at
+// most --split-size bytes, named after outputPath, e.g. out.md ->
+// out.part1.md, out.part2.md. Parts break only before a file or a closing
+// section, so a file larger than the cap gets a part of its own rather
+// than being cut. Each part opens with its number and the total.
+func (g *generator) writeParts(outputPath, root string, dir Directory, filePaths []string) error {
+	report := g.buildReport(root, dir, filePaths)
+	var buf bytes.Buffer
+	var breaks []int64
+	if err := (markdownRenderer{opts: g.opts, breaks: &breaks}).Render(&buf, report); err != nil {
+		return err
+	}
+	parts := splitDocument(buf.Bytes(), breaks, max(g.opts.SplitSize-partHeaderReserve, 1))
+
+	base, ext := splitOutputName(outputPath)
+	for i, part := range parts {
+		name := fmt.Sprintf("%v.part%d%v", base, i+1, ext)
+		f, err := createOutput(name)
+		if err != nil {
+			return fmt.Errorf("creating output file %s: %w", name, err)
+		}
+		fmt.Fprintf(f, "_Part %d of %d_\n\n", i+1, len(parts))
+		_, err = f.Write(part)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("writing output file %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// splitDocument cuts doc at some of the offsets in breaks (ascending) so
+// that each part is at most limit bytes, except where the stretch between
+// two neighbouring breaks is itself longer.
+func splitDocument(doc []byte, breaks []int64, limit int64) [][]byte {
+	var parts [][]byte
+	start, last := int64(0), int64(0)
+	for _, c := range append(breaks, int64(len(doc))) {
+		if c <= last {
+			continue
+		}
+		if c-start > limit && last > start {
+			parts = append(parts, doc[start:last])
+			start = last
+		}
+		last = c
+	}
+	if start < int64(len(doc)) {
+		parts = append(parts, doc[start:])
+	}
+	return parts
+}
+
+// isOutputPart reports whether path is one of the --split-size parts of
+// outputPath, e.g. out.part3.md for out.md.
+func isOutputPart(path, outputPath string) bool {
+	base, ext := splitOutputName(outputPath)
+	rest, ok := strings.CutPrefix(path, base+".part")
+	if !ok {
+		return false
+	}
+	num, ok := strings.CutSuffix(rest, ext)
+	return ok && num != "" && strings.Trim(num, "0123456789") == ""
+}
+
+// writeDocument renders the full document for dir (or for filePaths when
+// targeting individual files) in the selected format. root anchors ignore
+// rules.
+func (g *generator) writeDocument(w io.Writer, root string, dir Directory, filePaths []string) error {
+	report := g.buildReport(root, dir, filePaths)
+	if g.opts.MaxOutputLines <= 0 {
+		return newRenderer(g.opts).Render(w, report)
+	}
+
+	lw := &lineLimitWriter{w: w, remaining: g.opts.MaxOutputLines}
+	if err := newRenderer(g.opts).Render(lw, report); err != nil {
+		return err
+	}
+	if lw.truncated {
+		_, err := fmt.Fprintf(w, "\n... output truncated at %v lines (--max-output-lines)\n", g.opts.MaxOutputLines)
+		return err
+	}
+	return nil
+}
+
+// countingWriter passes everything through to w, counting the bytes
+// written.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// lineLimitWriter passes the first remaining lines through to w and drops
+// everything after them, recording that it did so.
+type lineLimitWriter struct {
+	w         io.Writer
+	remaining int
+	truncated bool
+}
+
+func (l *lineLimitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if l.remaining <= 0 {
+		l.truncated = l.truncated || n > 0
+		return n, nil
+	}
+	for i, b := range p {
+		if b != '\n' {
+			continue
+		}
+		l.remaining--
+		if l.remaining == 0 {
+			l.truncated = i+1 < n
+			p = p[:i+1]
+			break
+		}
+	}
+	if _, err := l.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,785 @@
+package reporeader
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whoisrgxu/myreporeader/internal/rank"
+	"github.com/whoisrgxu/myreporeader/internal/tokens"
+)
+
+// Report is everything gathered about a target, independent of the output
+// format. Renderers turn it into Markdown or JSON.
+type Report struct {
+	// Location shown for the target; the archive path for archive targets
+	Root string `json:"root"`
+	// Latest commit, or nil outside a repository and for archives
+	Git       *GitInfo  `json:"git"`
+	Structure *Node     `json:"structure"`
+	Files     []File    `json:"files"`
+	Summary   Summary   `json:"summary"`
+	Ignored   []Ignored `json:"ignored,omitempty"`
+	// Files left out of Files by --max-tokens
+	Omitted int `json:"omitted,omitempty"`
+	// Leading comment block stripped from several files by --dedup-headers
+	SharedHeader string `json:"sharedHeader,omitempty"`
+	// Non-text files left out of Files, for --list-binaries
+	Binaries []Binary `json:"binaries,omitempty"`
+
+	// Files selected by collect, read afterwards by addFiles
+	pending []string
+}
+
+// Node is an entry in the structure tree. Directories carry their children
+// and, with --dir-stats, their recursive counts.
+type Node struct {
+	Name     string   `json:"name"`
+	Dir      bool     `json:"dir"`
+	Stats    *DirStat `json:"stats,omitempty"`
+	Children []*Node  `json:"children,omitempty"`
+	// Number of identically structured sibling directories this one
+	// stands for under --collapse-similar-dirs, itself included
+	Collapsed int `json:"collapsed,omitempty"`
+	// Set on directories beyond --max-depth, whose entries were not read
+	Truncated bool `json:"truncated,omitempty"`
+	// Target of a symlinked directory that was not followed
+	Link string `json:"link,omitempty"`
+	// Set on text files longer than --large-file-lines
+	Large bool `json:"large,omitempty"`
+	// Modification time, for --show-mtime
+	Modified *time.Time `json:"modified,omitempty"`
+}
+
+// DirStat holds the recursive per-directory counts for --dir-stats.
+type DirStat struct {
+	Files int `json:"files"`
+	Lines int `json:"lines"`
+}
+
+// File is a printed file. Error is set instead of Content when the file
+// could not be read, and Skipped when it exceeds --max-file-size.
+type File struct {
+	Path     string   `json:"path"`
+	Language string   `json:"language,omitempty"`
+	Lines    int      `json:"lines"`
+	Tokens   int      `json:"tokens"`
+	Content  string   `json:"content"`
+	Status   string   `json:"status,omitempty"`
+	Notes    []string `json:"notes,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Skipped  string   `json:"skipped,omitempty"`
+	// Lines cut from the end of Content by --max-lines; Lines still counts
+	// them
+	OmittedLines int `json:"omittedLines,omitempty"`
+	// Content is a hexdump -C style dump of a --hexdump-ext file
+	Hexdump bool      `json:"hexdump,omitempty"`
+	Meta    *FileMeta `json:"meta,omitempty"`
+	// Hex SHA-256 of the file's bytes on disk, for --hashes
+	Hash string `json:"sha256,omitempty"`
+	// Other files in the report whose paths this one mentions, for
+	// --link-references
+	References []string `json:"references,omitempty"`
+
+	absPath string
+}
+
+// FileMeta is the --include-file-metadata stat information of a file.
+type FileMeta struct {
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Mode     string    `json:"mode"`
+}
+
+// Summary holds the totals printed at the end of the document.
+type Summary struct {
+	Files int `json:"files"`
+	Lines int `json:"lines"`
+	// Blank and comment lines among Lines, comments detected per language;
+	// Code is the rest
+	Code    int `json:"code"`
+	Blank   int `json:"blank"`
+	Comment int `json:"comment"`
+	// Per-language counts, most lines first
+	Languages []LangStat `json:"languages"`
+	// Per-directory subtotals for --per-dir-summary, most lines first
+	Directories []DirSummary `json:"directories,omitempty"`
+	MaxDepth    int          `json:"maxDepth"`
+	// Estimated tokens of the file contents in the report
+	Tokens   int       `json:"tokens"`
+	GoOrigin *GoOrigin `json:"goOrigin,omitempty"`
+}
+
+// LangStat is one row of the Summary's per-language breakdown.
+type LangStat struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Lines    int    `json:"lines"`
+	Blank    int    `json:"blank"`
+	Comment  int    `json:"comment"`
+}
+
+// DirSummary is one row of the --per-dir-summary table. Path is relative
+// to the target, "." for the files directly in it.
+type DirSummary struct {
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Lines int    `json:"lines"`
+}
+
+// GoOrigin is the --go-origin split of the .go files under the target.
+type GoOrigin struct {
+	Module     string `json:"module"`
+	FirstParty int    `json:"firstParty"`
+	ThirdParty int    `json:"thirdParty"`
+}
+
+// Ignored is an ignored path with the rule responsible, for --print-ignored.
+type Ignored struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Binary is a file skipped as non-text, with its size in bytes, for
+// --list-binaries.
+type Binary struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// buildReport gathers the report for dir (or for filePaths when targeting
+// individual files). root anchors ignore rules.
+func (g *generator) buildReport(root string, dir Directory, filePaths []string) *Report {
+	location := dir.getPath()
+	r := &Report{Root: location, Files: []File{}}
+	defer g.progress.done()
+	if g.archiveSource != "" {
+		rel, _ := filepath.Rel(root, location)
+		r.Root = filepath.Join(g.archiveSource, rel)
+	}
+
+	r.Git = g.loadGit(root, dir)
+	if g.opts.DirStats {
+		g.dirStats = g.collectDirStats(root, location)
+	}
+
+	r.Structure = &Node{Name: filepath.Base(r.Root), Dir: true, Stats: g.dirStat(location)}
+	r.Summary.MaxDepth = g.collect(r, r.Structure, dir, root, len(filePaths) == 0, newVisited(location))
+	if g.opts.CollapseSimilarDirs {
+		collapseSimilarDirs(r.Structure)
+	}
+	if g.opts.StructureOnly {
+		// No file is read: contents, the Summary counts, and the ignored
+		// list are all skipped, not just left unprinted.
+		r.Summary.Languages = []LangStat{}
+		if g.opts.NormalizePathsPOSIX {
+			normalizePaths(r)
+		}
+		return r
+	}
+	for _, filePath := range filePaths {
+		if !g.opts.Force && g.isIgnored(filePath, root) || !g.isIncluded(filePath, root) || g.isDenied(filePath) || g.isExcludedGenerated(filePath) {
+			continue
+		}
+		r.pending = append(r.pending, filePath)
+	}
+	g.progress.start("reading")
+	g.addFiles(r, root)
+	if g.opts.DedupHeaders {
+		dedupHeaders(r)
+	}
+	if g.opts.Rank {
+		rankFiles(r)
+	}
+	if g.opts.GoDepOrder {
+		goDepOrder(r, location)
+	}
+	g.applyTokenBudget(r)
+	if g.opts.LinkReferences {
+		linkReferences(r)
+	}
+
+	// Summary (prefer Git-tracked; fallback to FS walk)
+	langs := &langCounts{location: location}
+	if g.opts.PerDirSummary {
+		langs.depth = max(g.opts.PerDirDepth, 1)
+	}
+	g.progress.start("counting")
+	if len(filePaths) == 0 {
+		if g.useGit {
+			r.Summary.Files, r.Summary.Lines = g.countFilesAndLinesGit(root, location, langs)
+		} else {
+			r.Summary.Files, r.Summary.Lines = g.countFilesAndLines(g.childPaths(dir, root), root, langs, newVisited(location))
+		}
+	} else {
+		r.Summary.Files, r.Summary.Lines = g.countFilesAndLines(filePaths, root, langs, newVisited(location))
+	}
+	r.Summary.Languages = langs.sorted()
+	r.Summary.Directories = langs.sortedDirs()
+	for _, l := range r.Summary.Languages {
+		r.Summary.Blank += l.Blank
+		r.Summary.Comment += l.Comment
+	}
+	r.Summary.Code = r.Summary.Lines - r.Summary.Blank - r.Summary.Comment
+
+	if g.opts.GoOrigin && len(filePaths) == 0 {
+		if module, first, third, ok := g.countGoOrigin(location); ok {
+			r.Summary.GoOrigin = &GoOrigin{Module: module, FirstParty: first, ThirdParty: third}
+		}
+	}
+
+	if g.opts.PrintIgnored && len(filePaths) == 0 {
+		r.Ignored = []Ignored{}
+		g.collectIgnored(r, dir, root)
+	}
+	if g.opts.NormalizePathsPOSIX {
+		normalizePaths(r)
+	}
+	return r
+}
+
+// loadGit runs the git commands the report needs up front: the latest
+// commit, the tracked files when root is a repository, and --git-status.
+// If any of them fails, none of their results are used, so a broken
+// repository falls back to the filesystem for the whole run instead of
+// mixing git and filesystem results.
+func (g *generator) loadGit(root string, dir Directory) *GitInfo {
+	g.useGit, g.trackedFiles, g.gitStatuses = false, nil, nil
+	if g.archiveSource != "" {
+		return nil
+	}
+	info, err := dir.GetLatestCommit(g.opts.GitDateRelative)
+	if err != nil {
+		return nil
+	}
+	repo := isGitRepo(root)
+	var tracked []string
+	if repo {
+		if tracked, err = listGitTrackedFiles(root, g.opts.IncludeUntracked); err != nil {
+			return nil
+		}
+	}
+	var statuses map[string]string
+	if g.opts.GitStatus {
+		if statuses, err = loadGitStatus(root); err != nil {
+			return nil
+		}
+	}
+	g.useGit, g.trackedFiles, g.gitStatuses = repo, tracked, statuses
+	return info
+}
+
+// normalizePaths rewrites every path rendered from r with posixPath, so
+// the same tree produces the same document on every OS.
+func normalizePaths(r *Report) {
+	r.Root = posixPath(r.Root)
+	for i := range r.Files {
+		f := &r.Files[i]
+		f.Path = posixPath(f.Path)
+		if f.Error != "" {
+			f.Error = strings.ReplaceAll(f.Error, f.absPath, posixPath(f.absPath))
+		}
+		f.absPath = posixPath(f.absPath)
+	}
+	for i := range r.Ignored {
+		r.Ignored[i].Path = posixPath(r.Ignored[i].Path)
+	}
+	for i := range r.Binaries {
+		r.Binaries[i].Path = posixPath(r.Binaries[i].Path)
+	}
+}
+
+// posixPath returns p with forward slashes and a lowercase volume name,
+// e.g. C:\src\app -> c:/src/app. Names keep their on-disk case, which
+// git preserves on every platform.
+func posixPath(p string) string {
+	vol := filepath.VolumeName(p)
+	return strings.ToLower(filepath.ToSlash(vol)) + filepath.ToSlash(p[len(vol):])
+}
+
+// collect adds the visible entries under d to node and, when withFiles is
+// set, the printable files to r. It returns the deepest directory nesting
+// level encountered, counting directories that --max-depth lists without
+// entering. visited tracks the directories entered, see enterDir.
+func (g *generator) collect(r *Report, node *Node, d Directory, root string, withFiles bool, visited map[string]bool) int {
+	path := d.getPath()
+	maxDepth := d.Depth
+
+	for _, entry := range g.getNonHiddenEntries(d.listEntries()) {
+		fullPath := filepath.Join(path, entry.Name())
+		if g.isIgnored(fullPath, root) {
+			continue
+		}
+
+		// --structure changed keeps the paths --since and --modified-since
+		// select; directories left empty by the latter are dropped below.
+		changedOnly := g.opts.Structure == "changed"
+		if changedOnly && g.changed != nil && !g.hasChanged(fullPath) {
+			continue
+		}
+		if entry.IsDir() || entry.Type()&os.ModeSymlink != 0 && isDir(fullPath) {
+			child := &Node{Name: entry.Name(), Dir: true, Stats: g.dirStat(fullPath), Modified: g.modTime(fullPath)}
+			node.Children = append(node.Children, child)
+			if g.beyondMaxDepth(d.Depth) {
+				child.Truncated = true
+				child.Stats = nil
+				maxDepth = max(maxDepth, d.Depth+1)
+				continue
+			}
+			if !g.enterDir(fullPath, visited) {
+				child.Link, _ = os.Readlink(fullPath)
+				child.Stats = nil
+				continue
+			}
+			childDir := Directory{ParentPath: path, Name: entry.Name(), Depth: d.Depth + 1}
+			if depth := g.collect(r, child, childDir, root, withFiles, visited); depth > maxDepth {
+				maxDepth = depth
+			}
+			if changedOnly && !g.opts.ModifiedSince.IsZero() && len(child.Children) == 0 {
+				node.Children = node.Children[:len(node.Children)-1]
+			}
+			continue
+		}
+		if changedOnly && !g.opts.ModifiedSince.IsZero() && !g.modifiedSince(fullPath) {
+			continue
+		}
+		node.Children = append(node.Children, &Node{Name: entry.Name(), Large: g.isLarge(fullPath, root), Modified: g.modTime(fullPath)})
+
+		if !withFiles || !g.isIncluded(fullPath, root) {
+			continue
+		}
+		if g.isDenied(fullPath) || g.isExcludedGenerated(fullPath) {
+			continue
+		}
+		r.pending = append(r.pending, fullPath)
+	}
+	return maxDepth
+}
+
+// modTime returns path's modification time for --show-mtime, or nil when
+// the option is off or path cannot be stat'ed.
+func (g *generator) modTime(path string) *time.Time {
+	if !g.opts.ShowMtime {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	t := info.ModTime()
+	return &t
+}
+
+// isLarge reports whether path is a text file with more lines than
+// --large-file-lines. Only files that could be printed are counted, so
+// binaries, denied files, and files over --max-file-size are never read in
+// full. It is always false with --structure-only, which reads no file.
+func (g *generator) isLarge(path string, root string) bool {
+	if g.opts.LargeFileLines <= 0 || g.opts.StructureOnly {
+		return false
+	}
+	if g.isDenied(path) || !g.isIncluded(path, root) || g.isHexdumpExt(path) {
+		return false
+	}
+	if g.opts.MaxFileSize > 0 {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() > g.opts.MaxFileSize {
+			return false
+		}
+	}
+	if !g.isTextFile(path, root) {
+		return false
+	}
+	lines, err := g.countFileLines(path, root)
+	return err == nil && lines.Lines > g.opts.LargeFileLines
+}
+
+// collapseSimilarDirs replaces each group of sibling directories that have
+// the same non-empty layout with its first member, recording the group size
+// in Collapsed, and then does the same inside the remaining directories.
+func collapseSimilarDirs(node *Node) {
+	groups := map[string]*Node{}
+	kept := node.Children[:0]
+	for _, child := range node.Children {
+		if child.Dir && len(child.Children) > 0 {
+			key := shape(child)
+			if first := groups[key]; first != nil {
+				first.Collapsed++
+				continue
+			}
+			child.Collapsed = 1
+			groups[key] = child
+		}
+		kept = append(kept, child)
+	}
+	node.Children = kept
+
+	for _, child := range node.Children {
+		if child.Collapsed == 1 {
+			child.Collapsed = 0
+		}
+		if child.Dir {
+			collapseSimilarDirs(child)
+		}
+	}
+}
+
+// shape describes the entries below node, so that directories with the
+// same layout share a shape whatever their own names.
+func shape(node *Node) string {
+	var b strings.Builder
+	for _, c := range node.Children {
+		b.WriteString(c.Name)
+		if c.Dir {
+			b.WriteString("/\x00" + shape(c) + "\x01")
+		}
+		b.WriteString("\x00")
+	}
+	return b.String()
+}
+
+// addFiles reads the pending files into r with up to --max-concurrency
+// workers, keeping them in the order they were selected.
+func (g *generator) addFiles(r *Report, root string) {
+	order := make(map[string]int, len(r.pending))
+	for i, path := range r.pending {
+		order[path] = i
+	}
+	added := make([]Report, len(r.pending))
+	g.forEachConcurrent(r.pending, func(path string) {
+		g.addFile(&added[order[path]], path, root)
+		for _, f := range added[order[path]].Files {
+			g.progress.add(f.Lines)
+		}
+	})
+	for _, part := range added {
+		r.Files = append(r.Files, part.Files...)
+		r.Binaries = append(r.Binaries, part.Binaries...)
+	}
+	r.pending = nil
+}
+
+// addFile reads path and appends it to r.Files if it is printable text.
+// A read failure is recorded on the entry rather than aborting the report.
+func (g *generator) addFile(r *Report, path string, root string) {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		relPath = path
+	}
+
+	if g.isHexdumpExt(path) {
+		g.addHexdump(r, path, relPath)
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		r.Files = append(r.Files, File{Path: relPath, Error: err.Error(), absPath: path})
+		return
+	}
+
+	// Oversized files are noted without being read into memory.
+	if g.opts.MaxFileSize > 0 {
+		if info.Size() > g.opts.MaxFileSize {
+			if g.isTextFile(path, root) {
+				r.Files = append(r.Files, File{
+					Path:    relPath,
+					Status:  g.gitStatuses[path],
+					Skipped: fmt.Sprintf("%v > %v", formatSize(info.Size()), formatSize(g.opts.MaxFileSize)),
+					absPath: path,
+				})
+			} else {
+				g.addBinary(r, relPath, info.Size())
+			}
+			return
+		}
+	}
+
+	// Only print text-ish files. This is decided afresh on every run, so
+	// a file reused below is still one the current options print.
+	if !g.isTextFile(path, root) {
+		g.addBinary(r, relPath, info.Size())
+		return
+	}
+
+	// With --incremental, unchanged files come from the previous run.
+	if f, ok := g.reuseFile(path, relPath, info, nil); ok {
+		f.Meta = g.fileMeta(info)
+		r.Files = append(r.Files, f)
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.Files = append(r.Files, File{Path: relPath, Error: err.Error(), absPath: path})
+		return
+	}
+	if f, ok := g.reuseFile(path, relPath, info, data); ok {
+		f.Meta = g.fileMeta(info)
+		r.Files = append(r.Files, f)
+		return
+	}
+
+	content, ok := g.textContent(data)
+	if !ok {
+		return
+	}
+	if g.opts.EOLGitattributes && g.gitattributeEOL(path, root) != "" {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	f := File{
+		Path:     relPath,
+		Language: g.identifyFileType(path, content),
+		Lines:    countLines(content),
+		Tokens:   tokens.Estimate(content),
+		Content:  content,
+		Status:   g.gitStatuses[path],
+		absPath:  path,
+	}
+	if g.opts.HygieneNotes {
+		f.Notes = hygieneNotes(content)
+	}
+	if g.opts.TrimTrailingSpace {
+		f.Content = trimTrailingWhitespace(content)
+		f.Tokens = tokens.Estimate(f.Content)
+	}
+	if g.opts.MaxLines > 0 && f.Lines > g.opts.MaxLines {
+		f.Content = headLines(f.Content, g.opts.MaxLines)
+		f.OmittedLines = f.Lines - g.opts.MaxLines
+		f.Tokens = tokens.Estimate(f.Content)
+	}
+	g.recordFile(f, info, data)
+	f.Meta = g.fileMeta(info)
+	if g.opts.Hashes {
+		f.Hash = hashBytes(data)
+	}
+	r.Files = append(r.Files, f)
+}
+
+// fileMeta returns the --include-file-metadata block for a file, or nil
+// when the option is off.
+func (g *generator) fileMeta(info os.FileInfo) *FileMeta {
+	if !g.opts.IncludeFileMetadata || info == nil {
+		return nil
+	}
+	return &FileMeta{Size: info.Size(), Modified: info.ModTime(), Mode: info.Mode().String()}
+}
+
+// applyTokenBudget totals the estimated tokens of r.Files and, with
+// --max-tokens, drops the files from the first one that would exceed the
+// budget onwards, recording how many were omitted.
+func (g *generator) applyTokenBudget(r *Report) {
+	total := 0
+	for i, f := range r.Files {
+		if g.opts.MaxTokens > 0 && total+f.Tokens > g.opts.MaxTokens {
+			r.Omitted = len(r.Files) - i
+			r.Files = r.Files[:i]
+			break
+		}
+		total += f.Tokens
+	}
+	r.Summary.Tokens = total
+}
+
+// A run of characters that can form a relative path, such as
+// "../lib/util.go" or "docs/setup.md".
+var pathTokenRe = regexp.MustCompile(`[A-Za-z0-9_./-]+`)
+
+// fileReferences returns, for each of files, the paths of the other
+// printed files it mentions. A mention counts only when it names another
+// printed file exactly, including its extension: relative to the
+// mentioning file's directory, or else to the root. Anything else in the
+// text, however path-like, is left alone.
+func fileReferences(files []File) [][]string {
+	printed := map[string]string{}
+	for _, f := range files {
+		if f.Content != "" && !f.Hexdump {
+			printed[filepath.ToSlash(f.Path)] = f.Path
+		}
+	}
+	refs := make([][]string, len(files))
+	for i, f := range files {
+		if _, ok := printed[filepath.ToSlash(f.Path)]; !ok {
+			continue
+		}
+		self := filepath.ToSlash(f.Path)
+		dir := path.Dir(self)
+		seen := map[string]bool{self: true}
+		for _, tok := range pathTokenRe.FindAllString(f.Content, -1) {
+			tok = strings.TrimRight(tok, ".")
+			if !strings.Contains(path.Base(tok), ".") {
+				continue
+			}
+			candidates := []string{path.Join(dir, tok)}
+			if !strings.HasPrefix(tok, ".") {
+				candidates = append(candidates, path.Clean(strings.TrimPrefix(tok, "/")))
+			}
+			for _, c := range candidates {
+				if orig, ok := printed[c]; ok && !seen[c] {
+					seen[c] = true
+					refs[i] = append(refs[i], orig)
+					break
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// linkReferences fills References for every printed file.
+func linkReferences(r *Report) {
+	for i, refs := range fileReferences(r.Files) {
+		r.Files[i].References = refs
+	}
+}
+
+// rankFiles orders r.Files by rank.Score, most important first, keeping
+// the tree order among equal scores. Inbound mentions are counted as for
+// --link-references.
+func rankFiles(r *Report) {
+	inbound := map[string]int{}
+	for _, refs := range fileReferences(r.Files) {
+		for _, ref := range refs {
+			inbound[ref]++
+		}
+	}
+	scores := map[string]int{}
+	for _, f := range r.Files {
+		scores[f.Path] = rank.Score(filepath.ToSlash(f.Path), inbound[f.Path])
+	}
+	sort.SliceStable(r.Files, func(i, j int) bool {
+		return scores[r.Files[i].Path] > scores[r.Files[j].Path]
+	})
+}
+
+// isHexdumpExt reports whether path has one of the --hexdump-ext extensions.
+func (g *generator) isHexdumpExt(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range g.opts.HexdumpExt {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// addBinary records a file skipped as non-text when --list-binaries is set.
+func (g *generator) addBinary(r *Report, relPath string, size int64) {
+	if g.opts.ListBinaries {
+		r.Binaries = append(r.Binaries, Binary{Path: relPath, Size: size})
+	}
+}
+
+// addHexdump appends path rendered as a hex dump, reading at most
+// --max-binary-size bytes.
+func (g *generator) addHexdump(r *Report, path string, relPath string) {
+	f := File{Path: relPath, Language: "text", Status: g.gitStatuses[path], Hexdump: true, absPath: path}
+	file, err := os.Open(path)
+	if err != nil {
+		f.Error = err.Error()
+		r.Files = append(r.Files, f)
+		return
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if g.opts.MaxBinarySize > 0 {
+		src = io.LimitReader(file, g.opts.MaxBinarySize)
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		f.Error = err.Error()
+		r.Files = append(r.Files, f)
+		return
+	}
+	f.Content = hexdump(data)
+	f.Lines = countLines(f.Content)
+	f.Tokens = tokens.Estimate(f.Content)
+	if info, err := file.Stat(); err == nil && info.Size() > int64(len(data)) {
+		f.Notes = append(f.Notes, fmt.Sprintf("first %v of %v", formatSize(int64(len(data))), formatSize(info.Size())))
+	}
+	r.Files = append(r.Files, f)
+}
+
+// hexdump formats data like hexdump -C: offset, sixteen hex bytes, and
+// their printable ASCII, ending with the total length as an offset.
+func hexdump(data []byte) string {
+	return hex.Dump(data) + fmt.Sprintf("%08x", len(data))
+}
+
+// collectIgnored lists the ignored entries under d with the rule
+// responsible. Ignored directories are listed once rather than descended
+// into.
+func (g *generator) collectIgnored(r *Report, d Directory, root string) {
+	path := d.getPath()
+	for _, entry := range g.getNonHiddenEntries(d.listEntries()) {
+		childPath := filepath.Join(path, entry.Name())
+		rel, _ := filepath.Rel(root, childPath)
+		rel = filepath.ToSlash(rel)
+		if reason := g.ignoreReason(childPath, root); reason != "" {
+			if entry.IsDir() {
+				rel += "/"
+			}
+			r.Ignored = append(r.Ignored, Ignored{Path: rel, Reason: reason})
+			continue
+		}
+		if entry.IsDir() {
+			g.collectIgnored(r, Directory{ParentPath: path, Name: entry.Name()}, root)
+		}
+	}
+}
+
+// dirStat returns the --dir-stats counts for a directory, or nil when the
+// option is off.
+func (g *generator) dirStat(path string) *DirStat {
+	if g.dirStats == nil {
+		return nil
+	}
+	if st := g.dirStats[path]; st != nil {
+		return st
+	}
+	return &DirStat{}
+}
+
+// header returns the file's Markdown heading text: its path followed by
+// any status, hygiene, and skip notes and the --hashes digest.
+func (f File) header() string {
+	notes := f.Notes
+	if f.Hexdump {
+		notes = append([]string{"hexdump"}, notes...)
+	}
+	if f.Status != "" {
+		notes = append([]string{f.Status}, notes...)
+	}
+	if f.Skipped != "" {
+		notes = append(notes, "skipped, "+f.Skipped)
+	}
+	if f.Hash != "" {
+		notes = append(notes, "sha256: "+f.Hash)
+	}
+	if len(notes) == 0 {
+		return f.Path
+	}
+	return fmt.Sprintf("%v (%v)", f.Path, strings.Join(notes, ", "))
+}
+
+// formatSize renders a byte count with a binary unit, e.g. "4.2 MB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, i := float64(n)/unit, 0
+	for value >= unit && i < 3 {
+		value /= unit
+		i++
+	}
+	s := strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0")
+	return s + " " + []string{"KB", "MB", "GB", "TB"}[i]
+}
@@ -0,0 +1,497 @@
+package reporeader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLargeFileMarker(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"big.txt":   strings.Repeat("line\n", 20),
+		"small.txt": "line\n",
+		"blob.bin":  strings.Repeat("\x00\n", 50),
+	})
+
+	out := generate(t, Options{Path: root, LargeFileLines: 10})
+	if !strings.Contains(out, "big.txt ⚠ large") {
+		t.Errorf("big.txt not marked large:\n%s", out)
+	}
+	for _, name := range []string{"small.txt ⚠", "blob.bin ⚠"} {
+		if strings.Contains(out, name) {
+			t.Errorf("%q marked large:\n%s", name, out)
+		}
+	}
+
+	// A file too big to print is not read to count its lines either.
+	out = generate(t, Options{Path: root, LargeFileLines: 10, MaxFileSize: 10})
+	if strings.Contains(out, "⚠ large") {
+		t.Errorf("file over --max-file-size marked large:\n%s", out)
+	}
+}
+
+func TestMaxDepthSummary(t *testing.T) {
+	for path, want := range map[string]int{
+		"a.txt":         0,
+		"a/b.txt":       1,
+		"a/b/c/d/e.txt": 4,
+	} {
+		root := writeTree(t, map[string]string{path: "x\n", "top.txt": "y\n"})
+		out := generate(t, Options{Path: root})
+		if line := fmt.Sprintf("- Max depth: %d\n", want); !strings.Contains(out, line) {
+			t.Errorf("%s: Summary lacks %q:\n%s", path, line, out)
+		}
+	}
+
+	// Directories cut off by --max-depth are still listed, so they count.
+	root := writeTree(t, map[string]string{"a/b/c.txt": "x\n", "top.txt": "y\n"})
+	depth := 0
+	out := generate(t, Options{Path: root, MaxDepth: &depth})
+	if !strings.Contains(out, "- Max depth: 1\n") {
+		t.Errorf("--max-depth 0: Summary lacks Max depth 1:\n%s", out)
+	}
+}
+
+func TestHygieneNotes(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"clean.txt": "a\nb\n",
+		"crlf.txt":  "a\r\nb\r\n",
+		"nonl.txt":  "a\nb",
+		"ws.txt":    "a  \nb\t\n",
+		"both.txt":  "a \nb",
+	})
+	out := generate(t, Options{Path: root, HygieneNotes: true})
+	want := []string{
+		"both.txt (trailing whitespace, no final newline)",
+		"clean.txt",
+		"crlf.txt",
+		"nonl.txt (no final newline)",
+		"ws.txt (trailing whitespace)",
+	}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+
+	out = generate(t, Options{Path: root})
+	if strings.Contains(out, "(no final newline)") || strings.Contains(out, "(trailing whitespace)") {
+		t.Errorf("notes without --hygiene-notes:\n%s", out)
+	}
+}
+
+func TestPrintIgnored(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":          "build/\n*.log\n",
+		"a.log":               "a\n",
+		"main.go":             "package main\n",
+		"gen.go":              "package main\n",
+		"build/out.txt":       "b\n",
+		"node_modules/x/i.js": "x\n",
+		"node_modules/x/j.js": "x\n",
+	})
+	out := generate(t, Options{Path: root, PrintIgnored: true, Exclude: []string{"gen.go"}})
+	// Directories are listed once, not file by file.
+	want := "## Ignored Files\n\n" +
+		"- a.log — .gitignore: `*.log`\n" +
+		"- build/ — .gitignore: `build/`\n" +
+		"- gen.go — --exclude: `gen.go`\n" +
+		"- node_modules/ — default: `node_modules/`\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+
+	if out := generate(t, Options{Path: root}); strings.Contains(out, "## Ignored Files") {
+		t.Errorf("ignored files listed without --print-ignored:\n%s", out)
+	}
+}
+
+func TestExcludeGeneratedGo(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"api.pb.go": "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n",
+		"api.go":    "package api\n",
+	})
+	out := generate(t, Options{Path: root, ExcludeGeneratedGo: true})
+	if got, want := fileHeaders(out), []string{"api.go"}; !slices.Equal(got, want) {
+		t.Errorf("prints %q, want %q", got, want)
+	}
+	if !strings.Contains(out, "- Total files: 1\n") {
+		t.Errorf("Summary counts the generated file:\n%s", out)
+	}
+}
+
+func TestDirStats(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"docs/r.md":    "d\ne\nf\n",
+		"src/a.go":     "a\nb\n",
+		"src/sub/c.go": "c\n",
+		"src/logo.png": "\x89PNG\x00\x00",
+		"top.txt":      "t\n",
+	})
+	want := "```\n" +
+		"├── docs/ (1 files, 3 lines)\n" +
+		"│   └── r.md\n" +
+		"├── src/ (2 files, 3 lines)\n" +
+		"│   ├── a.go\n" +
+		"│   ├── logo.png\n" +
+		"│   └── sub/ (1 files, 1 lines)\n" +
+		"│       └── c.go\n" +
+		"└── top.txt\n" +
+		"```\n"
+	if out := generate(t, Options{Path: root, DirStats: true}); !strings.Contains(out, want) {
+		t.Errorf("structure is not\n%s\n%s", want, out)
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"big.txt":   strings.Repeat("0123456789abcdef\n", 4096),
+		"small.txt": "small\n",
+	})
+	out := generate(t, Options{Path: root, MaxFileSize: 64 << 10})
+	want := []string{"big.txt (skipped, 68 KB > 64 KB)", "small.txt"}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("headers = %q, want %q", got, want)
+	}
+	if strings.Contains(out, "0123456789abcdef") {
+		t.Errorf("oversized file printed")
+	}
+
+	out = generate(t, Options{Path: root})
+	if got := fileHeaders(out); !slices.Equal(got, []string{"big.txt", "small.txt"}) {
+		t.Errorf("without a limit, headers = %q", got)
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"blob.bin": "\x00\x01\x02ABCDEFGHIJKLMNOPQ\xff",
+		"main.go":  "package main\n",
+	})
+	out := generate(t, Options{Path: root, HexdumpExt: []string{".bin"}})
+	want := "### File: blob.bin (hexdump)\n```text\n" +
+		"00000000  00 01 02 41 42 43 44 45  46 47 48 49 4a 4b 4c 4d  |...ABCDEFGHIJKLM|\n" +
+		"00000010  4e 4f 50 51 ff                                    |NOPQ.|\n" +
+		"00000015\n```\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks\n%s\n%s", want, out)
+	}
+
+	out = generate(t, Options{Path: root, HexdumpExt: []string{".bin"}, MaxBinarySize: 4})
+	want = "### File: blob.bin (hexdump, first 4 B of 21 B)\n```text\n" +
+		"00000000  00 01 02 41                                       |...A|\n" +
+		"00000004\n```\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output lacks\n%s\n%s", want, out)
+	}
+}
+
+func TestCollapseSimilarDirs(t *testing.T) {
+	files := map[string]string{"services/odd/README.md": "# odd\n"}
+	for _, s := range []string{"auth", "billing", "users"} {
+		files["services/"+s+"/go.mod"] = "module " + s + "\n"
+		files["services/"+s+"/src/main.go"] = "package main\n"
+	}
+	root := writeTree(t, files)
+
+	out := generate(t, Options{Path: root, CollapseSimilarDirs: true})
+	want := "```\n" +
+		"└── services/\n" +
+		"    ├── auth/ (x3)\n" +
+		"    │   ├── go.mod\n" +
+		"    │   └── src/\n" +
+		"    │       └── main.go\n" +
+		"    └── odd/\n" +
+		"        └── README.md\n" +
+		"```\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("structure is not\n%s\n%s", want, out)
+	}
+	// Only the structure is collapsed; every file is still printed.
+	if got := len(fileHeaders(out)); got != 7 {
+		t.Errorf("printed %d files, want 7", got)
+	}
+}
+
+func TestNormalizePaths(t *testing.T) {
+	// Paths are built with the OS separator, so on Windows this exercises
+	// the backslash and volume name handling.
+	native := func(p string) string { return filepath.FromSlash(p) }
+	root, wantRoot := native("/work/repo"), "/work/repo"
+	if filepath.Separator == '\\' {
+		root, wantRoot = `C:`+root, "c:/work/repo"
+	}
+	r := &Report{
+		Root: root,
+		Files: []File{{
+			Path:    native("src/main.go"),
+			Error:   "open " + filepath.Join(root, native("src/main.go")) + ": permission denied",
+			absPath: filepath.Join(root, native("src/main.go")),
+		}},
+		Ignored:  []Ignored{{Path: native("build/out.o")}},
+		Binaries: []Binary{{Path: native("assets/logo.png")}},
+	}
+	normalizePaths(r)
+
+	f := r.Files[0]
+	for _, c := range [][2]string{
+		{r.Root, wantRoot},
+		{f.Path, "src/main.go"},
+		{f.absPath, wantRoot + "/src/main.go"},
+		{f.Error, "open " + wantRoot + "/src/main.go: permission denied"},
+		{r.Ignored[0].Path, "build/out.o"},
+		{r.Binaries[0].Path, "assets/logo.png"},
+	} {
+		if c[0] != c[1] {
+			t.Errorf("normalized to %q, want %q", c[0], c[1])
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	root := syntheticTree(b, 3000, ".go")
+	for _, jobs := range []int{1, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			opts := Options{Path: root, MaxConcurrency: jobs, DirStats: true}
+			for b.Loop() {
+				if err := Generate(opts, io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/b/c/helper.go": "package c\n",
+		"lib.go":          "package main\n",
+		"main.go":         "package main\n",
+		"README.md":       "# demo\n",
+	})
+	out := generate(t, Options{Path: root, Rank: true})
+	want := []string{"main.go", "README.md", "lib.go", "a/b/c/helper.go"}
+	if got := fileHeaders(out); !slices.Equal(got, want) {
+		t.Errorf("--rank prints %q, want %q", got, want)
+	}
+}
+
+func TestExcludeSizeRange(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"tiny.txt":   "a\n",
+		"medium.txt": strings.Repeat("m", 499) + "\n",
+		"large.txt":  strings.Repeat("l", 4999) + "\n",
+	})
+	for _, tc := range []struct {
+		name   string
+		ranges []SizeRange
+		want   []string
+	}{
+		{"lower bound", []SizeRange{{Min: 1000, Max: -1}}, []string{"medium.txt", "tiny.txt"}},
+		{"upper bound", []SizeRange{{Min: 0, Max: 100}}, []string{"large.txt", "medium.txt"}},
+		{"two-sided", []SizeRange{{Min: 100, Max: 1000}}, []string{"large.txt", "tiny.txt"}},
+		// Ends are inclusive.
+		{"exact", []SizeRange{{Min: 500, Max: 500}}, []string{"large.txt", "tiny.txt"}},
+		{"several", []SizeRange{{Min: 0, Max: 2}, {Min: 5000, Max: -1}}, []string{"medium.txt"}},
+	} {
+		out := generate(t, Options{Path: root, ExcludeSizeRange: tc.ranges})
+		if got := fileHeaders(out); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: prints %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHashes(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":  "package main\n",
+		"long.txt": "a\nb\nc\n",
+	})
+	// The hash covers the raw bytes, not what --max-lines leaves printed.
+	out := generate(t, Options{Path: root, Hashes: true, MaxLines: 1})
+	for _, want := range []string{
+		"### File: main.go (sha256: df1d036cbbf3df46e2045071e082245ece204c7f53ecf0a4e022bff9bb228f47)\n",
+		"### File: long.txt (sha256: 880553fca8fcea94e325ee2cfb48e5a985cc797f39a14cc6d3cedecfeb2ae4d2)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+
+	out = generate(t, Options{Path: root, Hashes: true, Format: "json"})
+	if !strings.Contains(out, `"sha256": "df1d036cbbf3df46e2045071e082245ece204c7f53ecf0a4e022bff9bb228f47"`) {
+		t.Errorf("JSON lacks the sha256 field:\n%s", out)
+	}
+
+	if out := generate(t, Options{Path: root}); strings.Contains(out, "sha256") {
+		t.Errorf("hashes printed without --hashes:\n%s", out)
+	}
+}
+
+func TestTrimTrailingWhitespace(t *testing.T) {
+	for in, want := range map[string]string{
+		"a  \nb\t\n":     "a\nb\n",
+		"a \r\nb\t \r\n": "a\r\nb\r\n",
+		"  lead  ":       "  lead",
+		"\t\n \n":        "\n\n",
+		"":               "",
+	} {
+		if got := trimTrailingWhitespace(in); got != want {
+			t.Errorf("trimTrailingWhitespace(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	root := writeTree(t, map[string]string{"main.go": "package main \n\nfunc main() {\t\n}   \n"})
+	out := generate(t, Options{Path: root, TrimTrailingSpace: true})
+	if !strings.Contains(out, "package main\n\nfunc main() {\n}\n") {
+		t.Errorf("trailing whitespace kept:\n%s", out)
+	}
+	if !strings.Contains(out, "- Total lines: 4 ") {
+		t.Errorf("line count changed:\n%s", out)
+	}
+	if out := generate(t, Options{Path: root}); !strings.Contains(out, "package main \n") {
+		t.Errorf("trailing whitespace trimmed without --trim-trailing-whitespace:\n%s", out)
+	}
+}
+
+func TestModifiedSince(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"new.go":     "package main\n",
+		"old.go":     "package main\n\nfunc old() {}\n",
+		"pkg/new.go": "package pkg\n",
+		"pkg/old.go": "package pkg\n",
+		"stale/a.go": "package stale\n",
+	})
+	now := time.Now()
+	for name, age := range map[string]time.Duration{
+		"new.go":     time.Hour,
+		"pkg/new.go": 2 * time.Hour,
+		"old.go":     10 * 24 * time.Hour,
+		"pkg/old.go": 30 * 24 * time.Hour,
+		"stale/a.go": 30 * 24 * time.Hour,
+	} {
+		mtime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(name)), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	since := now.Add(-7 * 24 * time.Hour)
+
+	out := generate(t, Options{Path: root, ModifiedSince: since})
+	if got, want := fileHeaders(out), []string{"new.go", "pkg/new.go"}; !slices.Equal(got, want) {
+		t.Errorf("prints %q, want %q", got, want)
+	}
+	if !strings.Contains(out, "- Total files: 2\n") {
+		t.Errorf("Summary counts old files:\n%s", out)
+	}
+	if !strings.Contains(structureBlock(t, out), "stale/") {
+		t.Errorf("full Structure lacks stale/:\n%s", out)
+	}
+
+	out = generate(t, Options{Path: root, ModifiedSince: since, Structure: "changed"})
+	if block := structureBlock(t, out); strings.Contains(block, "old.go") || strings.Contains(block, "stale") {
+		t.Errorf("--structure changed shows old files:\n%s", block)
+	}
+}
+
+func TestRegexFilters(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":                  "package main\n",
+		"internal/a/a.go":          "package a\n",
+		"internal/a/a_test.go":     "package a\n",
+		"internal/b/b.go":          "package b\n",
+		"internal/b/b.md":          "# b\n",
+		"pkg/internalish/x.go":     "package internalish\n",
+		"pkg/internal/c/c.go":      "package c\n",
+		"pkg/internal/c/c_test.go": "package c\n",
+	})
+	for _, tc := range []struct {
+		name             string
+		include, exclude []string
+		exts             []string
+		want             []string
+	}{
+		{
+			name:    "include",
+			include: []string{`(^|/)internal/`},
+			want:    []string{"internal/a/a.go", "internal/a/a_test.go", "internal/b/b.go", "internal/b/b.md", "pkg/internal/c/c.go", "pkg/internal/c/c_test.go"},
+		},
+		{
+			name:    "include and exclude",
+			include: []string{`(^|/)internal/`},
+			exclude: []string{`_test\.go$`},
+			want:    []string{"internal/a/a.go", "internal/b/b.go", "internal/b/b.md", "pkg/internal/c/c.go"},
+		},
+		{
+			name:    "with --include",
+			include: []string{`^internal/`},
+			exts:    []string{".go"},
+			want:    []string{"internal/a/a.go", "internal/a/a_test.go", "internal/b/b.go"},
+		},
+		{
+			name:    "any of several",
+			include: []string{`^main\.go$`, `/x\.go$`},
+			want:    []string{"main.go", "pkg/internalish/x.go"},
+		},
+	} {
+		opts := Options{Path: root}
+		for _, re := range tc.include {
+			opts.IncludeRegex = append(opts.IncludeRegex, regexp.MustCompile(re))
+		}
+		for _, re := range tc.exclude {
+			opts.ExcludeRegex = append(opts.ExcludeRegex, regexp.MustCompile(re))
+		}
+		if tc.exts != nil {
+			opts.Include = map[string]struct{}{}
+			for _, ext := range tc.exts {
+				opts.Include[ext] = struct{}{}
+			}
+		}
+		out := generate(t, opts)
+		if got := fileHeaders(out); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: prints %q, want %q", tc.name, got, tc.want)
+		}
+		if total := fmt.Sprintf("- Total files: %d\n", len(tc.want)); !strings.Contains(out, total) {
+			t.Errorf("%v: Summary lacks %q:\n%s", tc.name, total, out)
+		}
+	}
+}
+
+func TestTextExt(t *testing.T) {
+	// A NUL byte makes the sniff alone call these binary.
+	content := "Feature: login\n\x00\n"
+	root := writeTree(t, map[string]string{
+		"login.feature": content,
+		"Brewfile":      content,
+		"main.go":       "package main\n",
+	})
+	out := generate(t, Options{Path: root})
+	if got, want := fileHeaders(out), []string{"main.go"}; !slices.Equal(got, want) {
+		t.Errorf("prints %q, want %q", got, want)
+	}
+
+	out = generate(t, Options{Path: root, TextExt: []string{".feature"}, TextNames: []string{"Brewfile"}})
+	if got, want := fileHeaders(out), []string{"Brewfile", "login.feature", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("--text-ext .feature --text-name Brewfile prints %q, want %q", got, want)
+	}
+}
+
+func TestIncludeExtensions(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":        "package main\n",
+		"api/svc.PROTO":  "syntax = \"proto3\";\n",
+		"web/app.ts":     "export {};\n",
+		"docs/readme.md": "# docs\n",
+		"Makefile":       "all:\n",
+	})
+	out := generate(t, Options{Path: root, Include: map[string]struct{}{".go": {}, ".proto": {}}})
+	if got, want := fileHeaders(out), []string{"api/svc.PROTO", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("--include .go,.proto prints %q, want %q", got, want)
+	}
+	if !strings.Contains(out, "- Total files: 2\n") {
+		t.Errorf("Summary counts excluded extensions:\n%s", out)
+	}
+}
@@ -0,0 +1,60 @@
+package reporeader
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Document headings that precede the file headers and therefore take part
+// in anchor de-duplication.
+var fixedHeadings = []string{
+	"Repository Context", "File System Location", "Git Info", "Structure",
+	"Shared Header", "Table of Contents", "File Contents",
+}
+
+// headingAnchor converts a heading into a GitHub-style anchor slug:
+// lowercase, punctuation dropped, spaces turned into hyphens.
+func headingAnchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// headingAnchors returns the anchor of each of headings as GitHub assigns
+// them after the fixed headings: repeated slugs get -1, -2, ... suffixes.
+func headingAnchors(headings []string) []string {
+	seen := map[string]int{}
+	for _, h := range fixedHeadings {
+		seen[headingAnchor(h)]++
+	}
+	anchors := make([]string, len(headings))
+	for i, h := range headings {
+		anchor := headingAnchor(h)
+		if n := seen[anchor]; n > 0 {
+			seen[anchor]++
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			seen[anchor] = 1
+		}
+		anchors[i] = anchor
+	}
+	return anchors
+}
+
+// printTOC writes a bulleted list linking to every heading in headings.
+func printTOC(w io.Writer, headings []string) {
+	fmt.Fprintf(w, "## Table of Contents\n\n")
+	for i, anchor := range headingAnchors(headings) {
+		fmt.Fprintf(w, "- [%v](#%v)\n", strings.TrimPrefix(headings[i], "File: "), anchor)
+	}
+	fmt.Fprintln(w)
+}
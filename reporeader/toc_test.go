@@ -0,0 +1,58 @@
+package reporeader
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHeadingAnchor(t *testing.T) {
+	for heading, want := range map[string]string{
+		"File: main.go":               "file-maingo",
+		"File: src/app_test.go":       "file-srcapp_testgo",
+		"File: Docs/Read Me.md":       "file-docsread-memd",
+		"File: a-b.txt (staged)":      "file-a-btxt-staged",
+		"Small files (under 3 lines)": "small-files-under-3-lines",
+	} {
+		if got := headingAnchor(heading); got != want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
+
+func TestTOC(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		// Three paths with the same slug, "file-ago".
+		"A.go": "package a\n",
+		"a/go": "x\n",
+		"a.go": "package a\n",
+	})
+	out := generate(t, Options{Path: root, TOC: true})
+
+	var names, anchors []string
+	for _, m := range regexp.MustCompile(`(?m)^- \[(.*)\]\(#(.*)\)$`).FindAllStringSubmatch(out, -1) {
+		names = append(names, m[1])
+		anchors = append(anchors, m[2])
+	}
+	if headers := fileHeaders(out); !slices.Equal(names, headers) {
+		t.Errorf("TOC entries = %q, want the file headers %q", names, headers)
+	}
+
+	seen := map[string]bool{}
+	for _, h := range fixedHeadings {
+		seen[headingAnchor(h)] = true
+	}
+	for _, a := range anchors {
+		if seen[a] {
+			t.Errorf("anchor %q is not unique", a)
+		}
+		seen[a] = true
+	}
+	if want := []string{"file-ago", "file-ago-1", "file-ago-2"}; !slices.Equal(anchors, want) {
+		t.Errorf("anchors = %q, want %q", anchors, want)
+	}
+	if !strings.Contains(out, "## Table of Contents\n") {
+		t.Errorf("no Table of Contents:\n%s", out)
+	}
+}
@@ -0,0 +1,131 @@
+package reporeader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is the part of a file's metadata watch mode compares.
+type fileState struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// watch regenerates the output whenever the target changes, polling every
+// --watch-debounce interval and waiting until changes settle for one full
+// interval before regenerating. A summary of each change goes to stderr.
+// Each run starts from a fresh generator so rule changes are picked up.
+func watch(opts Options, w io.Writer) error {
+	root := opts.Path
+	if !isDir(root) {
+		root = filepath.Dir(root)
+	}
+
+	g := newGenerator(opts)
+	if err := g.output(w); err != nil {
+		return err
+	}
+	prev := g.snapshotFiles(root)
+	for {
+		time.Sleep(opts.WatchDebounce)
+		cur := g.snapshotFiles(root)
+		if sameSnapshot(prev, cur) {
+			continue
+		}
+		for {
+			time.Sleep(opts.WatchDebounce)
+			next := g.snapshotFiles(root)
+			if sameSnapshot(cur, next) {
+				break
+			}
+			cur = next
+		}
+
+		reportChanges(os.Stderr, prev, cur)
+		g = newGenerator(opts)
+		if err := g.output(w); err != nil {
+			return err
+		}
+		prev = g.snapshotFiles(root)
+	}
+}
+
+// snapshotFiles records the non-hidden, non-ignored files under root,
+// leaving out the output file and its parts or chunks so regenerating does
+// not retrigger itself.
+func (g *generator) snapshotFiles(root string) map[string]fileState {
+	files := map[string]fileState{}
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		hidden := g.isHidden(d.Name())
+		if hidden || g.isIgnored(path, root) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || g.isOutput(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		files[filepath.ToSlash(rel)] = fileState{Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, st := range a {
+		if other, ok := b[path]; !ok || other != st {
+			return false
+		}
+	}
+	return true
+}
+
+// reportChanges writes the files added, removed, and modified between two
+// snapshots, one per line, after a one-line tally.
+func reportChanges(w io.Writer, prev, cur map[string]fileState) {
+	var added, removed, modified []string
+	for path, st := range cur {
+		old, ok := prev[path]
+		switch {
+		case !ok:
+			added = append(added, path)
+		case old != st:
+			modified = append(modified, path)
+		}
+	}
+	for path := range prev {
+		if _, ok := cur[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+
+	fmt.Fprintf(w, "Regenerating: %d added, %d removed, %d modified\n", len(added), len(removed), len(modified))
+	for _, p := range added {
+		fmt.Fprintf(w, "  + %v\n", p)
+	}
+	for _, p := range removed {
+		fmt.Fprintf(w, "  - %v\n", p)
+	}
+	for _, p := range modified {
+		fmt.Fprintf(w, "  ~ %v\n", p)
+	}
+}
@@ -0,0 +1,87 @@
+package reporeader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportChanges(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"keep.go":           "package a\n",
+		"old.go":            "package a\n",
+		"edit.go":           "package a\n",
+		"node_modules/x.js": "x\n",
+	})
+	g := newGenerator(Options{Path: root})
+	prev := g.snapshotFiles(root)
+
+	if err := os.Remove(filepath.Join(root, "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"sub/new.go":        "package sub\n",
+		"edit.go":           "package a\n\nfunc f() {}\n",
+		"node_modules/y.js": "y\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cur := g.snapshotFiles(root)
+	if sameSnapshot(prev, cur) {
+		t.Fatal("snapshots match after changes")
+	}
+
+	var b strings.Builder
+	reportChanges(&b, prev, cur)
+	want := "Regenerating: 1 added, 1 removed, 1 modified\n" +
+		"  + sub/new.go\n" +
+		"  - old.go\n" +
+		"  ~ edit.go\n"
+	if b.String() != want {
+		t.Errorf("summary =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	// A touch alone counts as a modification.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "keep.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if sameSnapshot(cur, g.snapshotFiles(root)) {
+		t.Error("touched file not noticed")
+	}
+}
+
+func TestSnapshotSkipsOutputs(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		opts  Options
+		files []string
+	}{
+		{"output", Options{}, []string{"out.md"}},
+		{"split-size", Options{SplitSize: 1 << 10}, []string{"out.part1.md", "out.part2.md"}},
+		{"chunk-by-directory", Options{ChunkByDirectory: true}, []string{"out.md", "out.src.md"}},
+	} {
+		tree := map[string]string{"src/a.go": "package a\n"}
+		for _, name := range tc.files {
+			tree[name] = "# out\n"
+		}
+		root := writeTree(t, tree)
+		tc.opts.Path = root
+		tc.opts.Output = filepath.Join(root, "out.md")
+		g := newGenerator(tc.opts)
+		g.outputFile = tc.opts.Output
+
+		snap := g.snapshotFiles(root)
+		if _, ok := snap["src/a.go"]; !ok || len(snap) != 1 {
+			t.Errorf("%s: snapshot = %v, want only src/a.go", tc.name, snap)
+		}
+	}
+}